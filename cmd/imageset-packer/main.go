@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/woozymasta/imageset-packer/internal/cli"
@@ -9,6 +10,9 @@ import (
 func main() {
 	if err := cli.Run(os.Args[1:]); err != nil {
 		// fmt.Fprintf(os.Stderr, "Error 1: %v\n", err)
+		if errors.Is(err, cli.ErrInterrupted) {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
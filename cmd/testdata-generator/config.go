@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the build config written by --emit-config.
+const configFileName = ".imageset-packer.yaml"
+
+// configDoc mirrors the subset of the build config used by emitted projects.
+type configDoc struct {
+	Projects []configProject `yaml:"projects"`
+}
+
+// configProject is one build project.
+type configProject struct {
+	Name  string        `yaml:"name"`
+	Args  configArgs    `yaml:"args"`
+	Path  string        `yaml:"edds_path"`
+	Pack  configPacking `yaml:"packing"`
+	Input configInput   `yaml:"input,omitempty"`
+	Force bool          `yaml:"force"`
+	Skip  bool          `yaml:"skip_unchanged"`
+}
+
+// configArgs holds project input and output directories.
+type configArgs struct {
+	Input  string `yaml:"input_dir"`
+	Output string `yaml:"output_dir"`
+}
+
+// configPacking holds packing options that differ between projects.
+type configPacking struct {
+	OutputFormat string `yaml:"out_format"`
+	Rule         string `yaml:"rule,omitempty"`
+	Gap          int    `yaml:"gap,omitempty"`
+	Mipmaps      int    `yaml:"mipmaps,omitempty"`
+	Quality      int    `yaml:"quality,omitempty"`
+	ForceSquare  bool   `yaml:"force_square,omitempty"`
+	AllowRotate  bool   `yaml:"rotate,omitempty"`
+}
+
+// configInput holds input discovery options.
+type configInput struct {
+	GroupSeparator string `yaml:"group_separator,omitempty"`
+	MaxInputSide   int    `yaml:"max_input_side,omitempty"`
+	GroupDirs      bool   `yaml:"group_dirs,omitempty"`
+}
+
+// emitConfig writes a build config next to the generated dataset with a few
+// representative projects over the same inputs: a plain bgra8 atlas, a DXT5
+// atlas with gap and rotation, and a downscaled square DXT1 atlas.
+// Outputs go to the "out" subdirectory, which discovery ignores as it holds
+// no images.
+func emitConfig(opts *Options) (string, error) {
+	absDir, err := filepath.Abs(opts.Args.OutputDir)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(absDir)
+	input := datasetInput(opts)
+
+	doc := configDoc{Projects: []configProject{
+		{
+			Name:  base,
+			Pack:  configPacking{OutputFormat: "bgra8"},
+			Input: input,
+		},
+		{
+			Name:  base + "_dxt5",
+			Pack:  configPacking{OutputFormat: "dxt5", Rule: "bssf", Gap: 4, AllowRotate: true},
+			Input: input,
+		},
+		{
+			Name: base + "_dxt1_small",
+			Pack: configPacking{OutputFormat: "dxt1", Mipmaps: 1, Quality: 8, ForceSquare: true},
+			Input: configInput{
+				GroupSeparator: input.GroupSeparator,
+				GroupDirs:      input.GroupDirs,
+				MaxInputSide:   max(opts.MinSize, opts.MaxSize/4),
+			},
+		},
+	}}
+	for i := range doc.Projects {
+		doc.Projects[i].Args = configArgs{Input: ".", Output: "out"}
+		doc.Projects[i].Path = "test/" + base
+		doc.Projects[i].Force = true
+		doc.Projects[i].Skip = true
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by testdata-generator for %s.\n", base)
+	fmt.Fprintf(&buf, "# Run: imageset-packer build %s\n", filepath.ToSlash(filepath.Join(opts.Args.OutputDir, configFileName)))
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	path := filepath.Join(opts.Args.OutputDir, configFileName)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/woozymasta/bcn"
+	"github.com/woozymasta/imageset"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// DDS header field offsets from the start of the file (after "DDS ").
+const (
+	ddsOffHeaderSize = 4
+	ddsOffHeight     = 12
+	ddsOffWidth      = 16
+	ddsOffMipCount   = 28
+	ddsOffFourCC     = 84
+	ddsHeaderEnd     = 128
+	ddsDX10Size      = 20
+
+	// corruptSide is the size of the reference image. It is large enough for
+	// EDDS to store the base mip as an LZ4 block.
+	corruptSide = 128
+	// corruptManifest lists generated cases with a short description.
+	corruptManifest = "cases.txt"
+)
+
+// corruptCase is one malformed file derived from a valid one.
+type corruptCase struct {
+	name string
+	desc string
+	data []byte
+}
+
+// runCorrupt writes valid reference DDS/EDDS/imageset files and malformed
+// variants of them: truncated headers, lying mip counts and block sizes,
+// broken LZ4 chunk streams and invalid imageset syntax. opts.Count random
+// byte-flip variants are added per format for fuzz-style coverage.
+func runCorrupt(opts *Options, rng *rand.Rand) error {
+	dir := opts.Args.OutputDir
+	img := renderImage(0, corruptSide, corruptSide, shapeBox, rng)
+
+	ddsPath := filepath.Join(dir, "valid.dds")
+	if err := imageio.WriteWithOptions(ddsPath, img, &imageio.EncodeSettings{Format: bcn.FormatDXT5}); err != nil {
+		return fmt.Errorf("failed to write reference DDS: %w", err)
+	}
+	eddsPath := filepath.Join(dir, "valid.edds")
+	if err := imageio.WriteWithOptions(eddsPath, img, nil); err != nil {
+		return fmt.Errorf("failed to write reference EDDS: %w", err)
+	}
+	imagesetData, err := validImageset()
+	if err != nil {
+		return fmt.Errorf("failed to format reference imageset: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "valid.imageset"), imagesetData, 0600); err != nil {
+		return fmt.Errorf("failed to write reference imageset: %w", err)
+	}
+
+	ddsData, err := os.ReadFile(ddsPath)
+	if err != nil {
+		return err
+	}
+	eddsData, err := os.ReadFile(eddsPath)
+	if err != nil {
+		return err
+	}
+
+	var cases []corruptCase
+	cases = append(cases, ddsCases("dds", ddsData)...)
+	cases = append(cases, ddsCases("edds", eddsData)...)
+	cases = append(cases, eddsCases(eddsData, rng)...)
+	cases = append(cases, imagesetCases(imagesetData)...)
+	for i := 0; i < opts.Count; i++ {
+		cases = append(cases,
+			flipCase(fmt.Sprintf("dds_flip_%03d.dds", i), ddsData, rng),
+			flipCase(fmt.Sprintf("edds_flip_%03d.edds", i), eddsData, rng),
+			flipCase(fmt.Sprintf("imageset_flip_%03d.imageset", i), imagesetData, rng),
+		)
+	}
+
+	var manifest strings.Builder
+	for _, c := range cases {
+		if err := os.WriteFile(filepath.Join(dir, c.name), c.data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.name, err)
+		}
+		fmt.Fprintf(&manifest, "%s\t%s\n", c.name, c.desc)
+	}
+	if err := os.WriteFile(filepath.Join(dir, corruptManifest), []byte(manifest.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Printf("Successfully generated %d corrupt files in %s\n", len(cases), dir)
+	return nil
+}
+
+// validImageset returns a small imageset referencing valid.edds.
+func validImageset() ([]byte, error) {
+	half := corruptSide / 2
+	return imageset.Format(&imageset.Document{
+		Name:     "valid",
+		RefSize:  imageset.Size{Width: corruptSide, Height: corruptSide},
+		Textures: []imageset.Texture{{Mpix: 1, Path: "valid.edds"}},
+		Images: []imageset.Image{
+			{Name: "left", Pos: imageset.Point{}, Size: imageset.Size{Width: half, Height: corruptSide}},
+			{Name: "right", Pos: imageset.Point{X: half}, Size: imageset.Size{Width: half, Height: corruptSide}},
+		},
+	}, nil)
+}
+
+// ddsCases returns header-level corruptions shared by DDS and EDDS.
+func ddsCases(ext string, valid []byte) []corruptCase {
+	name := func(s string) string { return ext + "_" + s + "." + ext }
+
+	return []corruptCase{
+		{name("empty"), "zero-length file", nil},
+		{name("truncated_magic"), "file ends inside the magic", bytes.Clone(valid[:2])},
+		{name("truncated_header"), "file ends inside the DDS header", bytes.Clone(valid[:64])},
+		{name("truncated_data"), "pixel data cut in half", bytes.Clone(valid[:ddsHeaderEnd+(len(valid)-ddsHeaderEnd)/2])},
+		{name("bad_magic"), "magic is not \"DDS \"", patch(valid, 0, []byte("XDS "))},
+		{name("bad_header_size"), "header dwSize is 0 instead of 124", patchU32(valid, ddsOffHeaderSize, 0)},
+		{name("zero_dimensions"), "width and height are 0", patchU32(patchU32(valid, ddsOffWidth, 0), ddsOffHeight, 0)},
+		{name("huge_dimensions"), "width and height are 2^30", patchU32(patchU32(valid, ddsOffWidth, 1<<30), ddsOffHeight, 1<<30)},
+		{name("mipcount_huge"), "mip count is 0xFFFFFFFF", patchU32(valid, ddsOffMipCount, 0xFFFFFFFF)},
+		{name("mipcount_excess"), "mip count exceeds the chain for the size", patchU32(valid, ddsOffMipCount, 40)},
+		{name("unknown_fourcc"), "pixel format FourCC is unknown", patch(valid, ddsOffFourCC, []byte("XXXX"))},
+	}
+}
+
+// eddsCases returns corruptions of the EDDS block table and block bodies.
+func eddsCases(valid []byte, rng *rand.Rand) []corruptCase {
+	tableStart := ddsHeaderEnd
+	if string(valid[ddsOffFourCC:ddsOffFourCC+4]) == "DX10" {
+		tableStart += ddsDX10Size
+	}
+	mips := int(binary.LittleEndian.Uint32(valid[ddsOffMipCount:]))
+	dataStart := tableStart + mips*8
+
+	// The table and the bodies run from the smallest mip to the base one,
+	// so the last entry is the largest block.
+	last := tableStart + (mips-1)*8
+	lastBody := dataStart
+	for i := 0; i < mips-1; i++ {
+		lastBody += int(binary.LittleEndian.Uint32(valid[tableStart+i*8+4:]))
+	}
+	lastSize := binary.LittleEndian.Uint32(valid[last+4:])
+
+	cases := []corruptCase{
+		{"edds_truncated_table.edds", "file ends inside the block table", bytes.Clone(valid[:tableStart+4])},
+		{"edds_truncated_body.edds", "last block body is cut short", bytes.Clone(valid[:len(valid)-16])},
+		{"edds_mipcount_zero.edds", "mip count is 0, no block table", patchU32(valid, ddsOffMipCount, 0)},
+		{"edds_mipcount_more_blocks.edds", "mip count is larger than the number of blocks", patchU32(valid, ddsOffMipCount, uint32(mips+3))}, //nolint:gosec // small count
+		{"edds_mipcount_fewer_blocks.edds", "mip count is smaller than the number of blocks", patchU32(valid, ddsOffMipCount, 1)},
+		{"edds_unknown_block_magic.edds", "block table entry has an unknown magic", patch(valid, tableStart, []byte("ZZZZ"))},
+		{"edds_negative_block_size.edds", "block size is negative", patchU32(valid, last+4, 0xFFFFFFFF)},
+		{"edds_block_size_past_eof.edds", "block size points past the end of file", patchU32(valid, last+4, lastSize+1<<20)},
+		{"edds_block_size_short.edds", "block size is smaller than its body", patchU32(valid, last+4, lastSize-16)},
+	}
+
+	if string(valid[last:last+4]) != "LZ4 " {
+		return cases
+	}
+
+	// LZ4 body: u32 uncompressed size, then chunks of u24 size + flags byte.
+	garbage := make([]byte, 32)
+	_, _ = rng.Read(garbage)
+
+	return append(cases,
+		corruptCase{"edds_lz4_size_huge.edds", "LZ4 uncompressed size is 0x7FFFFFFF", patchU32(valid, lastBody, 0x7FFFFFFF)},
+		corruptCase{"edds_lz4_size_short.edds", "LZ4 uncompressed size is smaller than the mip", patchU32(valid, lastBody, 16)},
+		corruptCase{"edds_lz4_chunk_past_block.edds", "LZ4 chunk size runs past the block", patch(valid, lastBody+4, []byte{0xFF, 0xFF, 0x7F})},
+		corruptCase{"edds_lz4_chunk_zero.edds", "LZ4 chunk size is 0", patch(valid, lastBody+4, []byte{0, 0, 0})},
+		corruptCase{"edds_lz4_no_last_flag.edds", "last LZ4 chunk is not flagged as last", patch(valid, lastBody+7, []byte{0})},
+		corruptCase{"edds_lz4_garbage.edds", "LZ4 chunk payload is random bytes", patch(valid, lastBody+8, garbage)},
+	)
+}
+
+// imagesetCases returns syntax and semantic corruptions of an imageset.
+func imagesetCases(valid []byte) []corruptCase {
+	text := string(valid)
+	half := corruptSide / 2
+	replace := func(old, repl string) []byte {
+		return []byte(strings.Replace(text, old, repl, 1))
+	}
+
+	return []corruptCase{
+		{"imageset_empty.imageset", "zero-length file", nil},
+		{"imageset_truncated.imageset", "file ends in the middle of a block", bytes.Clone(valid[:len(valid)/2])},
+		{"imageset_unbalanced.imageset", "closing brace of the root block is missing", []byte(text[:strings.LastIndex(text, "}")])},
+		{"imageset_bad_number.imageset", "position is not a number", replace("Pos 0 0", "Pos x 0")},
+		{"imageset_negative_size.imageset", "image size is negative", replace(fmt.Sprintf("Size %d %d", half, corruptSide), fmt.Sprintf("Size -%d %d", half, corruptSide))},
+		{"imageset_out_of_bounds.imageset", "image lies outside RefSize", replace("Pos 0 0", "Pos 100000 100000")},
+		{"imageset_duplicate_names.imageset", "two images share one name", replace(`"right"`, `"left"`)},
+		{"imageset_missing_texture.imageset", "texture path points to a missing file", replace("valid.edds", "missing.edds")},
+		{"imageset_binary.imageset", "binary data instead of text", patch(valid, 0, []byte{0x00, 0xFF, 0xFE, 0x00, 0x89, 'P', 'N', 'G'})},
+	}
+}
+
+// flipCase flips a few random bytes, mostly in the first 256 bytes where
+// headers and tables live.
+func flipCase(name string, valid []byte, rng *rand.Rand) corruptCase {
+	data := bytes.Clone(valid)
+	limit := min(len(data), 256)
+	flips := 1 + rng.Intn(4)
+
+	offsets := make([]string, 0, flips)
+	for i := 0; i < flips; i++ {
+		off := rng.Intn(limit)
+		//nolint:gosec // 1 + Intn(255) is always within uint8.
+		data[off] ^= byte(1 + rng.Intn(255))
+		offsets = append(offsets, fmt.Sprintf("%d", off))
+	}
+
+	return corruptCase{name, "random bytes flipped at offsets " + strings.Join(offsets, ","), data}
+}
+
+// patch returns a copy of data with repl written at off.
+func patch(data []byte, off int, repl []byte) []byte {
+	out := bytes.Clone(data)
+	copy(out[off:], repl)
+	return out
+}
+
+// patchU32 returns a copy of data with a little-endian uint32 written at off.
+func patchU32(data []byte, off int, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return patch(data, off, buf[:])
+}
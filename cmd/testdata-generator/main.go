@@ -12,8 +12,6 @@ import (
 	"github.com/jessevdk/go-flags"
 	"github.com/woozymasta/png"
 	"golang.org/x/image/font"
-	"golang.org/x/image/font/gofont/gobold"
-	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -27,6 +25,16 @@ type Options struct {
 	Count        int  `short:"c" long:"count" description:"Number of images to generate" default:"10"`
 	MaxRatio     int  `short:"r" long:"max-ratio" description:"Maximum side ratio (1=squares only, 4=one side can be 4x larger)" default:"1"`
 	AllowNonPow2 bool `short:"n" long:"allow-non-pow2" description:"Allow non-power-of-2 sizes"`
+
+	Preset         string `short:"p" long:"preset" description:"Size preset; other than random, generates known hard packing cases" choice:"random" choice:"identical" choice:"strips" choice:"huge-tiny" default:"random"`
+	Seed           int64  `long:"seed" description:"Random seed for reproducible datasets (0 = time based)" default:"0"`
+	Naming         string `long:"naming" description:"File naming, matching pack discovery modes" choice:"plain" choice:"separator" choice:"dirs" choice:"sequence" default:"plain"`
+	GroupSeparator string `long:"group-separator" description:"Group separator for --naming separator" default:"_"`
+	Groups         int    `long:"groups" description:"Number of groups for --naming separator/dirs" default:"4"`
+	Frames         int    `long:"frames" description:"Frames per sequence for --naming sequence" default:"8"`
+	Corrupt        bool   `long:"corrupt" description:"Write malformed DDS/EDDS/imageset files instead of PNGs (count = random byte-flip variants per format)"`
+	EmitConfig     bool   `long:"emit-config" description:"Write a .imageset-packer.yaml with sample projects over the generated dataset"`
+	Shape          string `short:"s" long:"shape" description:"Sprite silhouette on a transparent background (box = opaque rectangle)" choice:"box" choice:"circle" choice:"rounded" choice:"star" choice:"text" choice:"mixed" default:"box"`
 }
 
 func main() {
@@ -64,24 +72,54 @@ func run(opts *Options) error {
 	if opts.MaxRatio < 1 {
 		return fmt.Errorf("max-ratio must be >= 1")
 	}
+	if opts.Groups < 1 || opts.Frames < 1 {
+		return fmt.Errorf("groups and frames must be positive")
+	}
+	if opts.Naming == namingSeparator && opts.GroupSeparator == "" {
+		return fmt.Errorf("group-separator must not be empty")
+	}
 
 	// Create output directory.
 	if err := os.MkdirAll(opts.Args.OutputDir, 0750); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
 	//nolint:gosec // Non-crypto randomness is fine for test data.
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rng := rand.New(rand.NewSource(seed))
+
+	if opts.Corrupt {
+		return runCorrupt(opts, rng)
+	}
+
+	sizes := planSizes(rng, opts)
+	if opts.Naming == namingSequence {
+		sequenceSizes(sizes, opts.Frames)
+	}
 
 	// Generate images.
-	for i := 0; i < opts.Count; i++ {
-		width, height := generateSize(rng, opts)
-		if err := generateImage(opts.Args.OutputDir, i, width, height, rng); err != nil {
+	for i, size := range sizes {
+		width, height := size[0], size[1]
+		shape := pickShape(opts.Shape, rng)
+		filename := filepath.Join(opts.Args.OutputDir, imageName(opts, i, width, height))
+		if err := generateImage(filename, i, width, height, shape, rng); err != nil {
 			return fmt.Errorf("failed to generate image %d: %w", i, err)
 		}
 	}
 
 	fmt.Printf("Successfully generated %d images in %s\n", opts.Count, opts.Args.OutputDir)
+
+	if opts.EmitConfig {
+		path, err := emitConfig(opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Build config written to %s\n", path)
+	}
+
 	return nil
 }
 
@@ -176,7 +214,29 @@ func generateSize(rng *rand.Rand, opts *Options) (width, height int) {
 }
 
 // generateImage creates a PNG image with simple visual markers.
-func generateImage(outputDir string, index, width, height int, rng *rand.Rand) error {
+// Shapes other than box are cut out of the background with soft alpha edges.
+func generateImage(filename string, index, width, height int, shape string, rng *rand.Rand) error {
+	img := renderImage(index, width, height, shape, rng)
+
+	// Save the file.
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return nil
+}
+
+// renderImage draws a sprite with background, border, diagonal and label.
+func renderImage(index, width, height int, shape string, rng *rand.Rand) *image.RGBA {
 	// Create image.
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 
@@ -221,19 +281,11 @@ func generateImage(outputDir string, index, width, height int, rng *rand.Rand) e
 	labelSize := float64(min(width, height)) * 0.5
 	drawCenteredLabel(img, fmt.Sprintf("%d", index+1), labelSize, labelColor)
 
-	// Save the file.
-	filename := filepath.Join(outputDir, fmt.Sprintf("test_%03d_%dx%d.png", index, width, height))
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer func() { _ = file.Close() }()
-
-	if err := png.Encode(file, img); err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+	if mask := shapeMask(shape, width, height, rng); mask != nil {
+		applyMask(img, mask)
 	}
 
-	return nil
+	return img
 }
 
 func drawDiagonal(img *image.RGBA, c color.RGBA) {
@@ -274,19 +326,10 @@ func drawCenteredLabel(img *image.RGBA, label string, size float64, c color.RGBA
 	if size < 6 {
 		return
 	}
-	tt, err := opentype.Parse(gobold.TTF)
-	if err != nil {
-		return
-	}
-	face, err := opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingNone,
-	})
+	face, err := resources.face(size)
 	if err != nil {
 		return
 	}
-	defer func() { _ = face.Close() }()
 
 	bounds, _ := font.BoundString(face, label)
 	textW := (bounds.Max.X - bounds.Min.X).Ceil()
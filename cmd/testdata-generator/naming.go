@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Naming modes.
+const (
+	namingPlain     = "plain"
+	namingSeparator = "separator"
+	namingDirs      = "dirs"
+	namingSequence  = "sequence"
+)
+
+// imageName returns the path of image index relative to the output directory.
+// Names stay unique across groups, as pack requires:
+//
+//   - plain:     test_007_64x32.png
+//   - separator: group02<sep>img_007_64x32.png (pack --group-separator)
+//   - dirs:      group_02/img_007_64x32.png (pack --group-dirs)
+//   - sequence:  anim02_003.png (frame 3 of sequence 2, all frames same size)
+func imageName(opts *Options, index, width, height int) string {
+	switch opts.Naming {
+	case namingSeparator:
+		return fmt.Sprintf("group%02d%simg_%03d_%dx%d.png", groupOf(opts, index)+1, opts.GroupSeparator, index, width, height)
+
+	case namingDirs:
+		return filepath.Join(fmt.Sprintf("group_%02d", groupOf(opts, index)+1), fmt.Sprintf("img_%03d_%dx%d.png", index, width, height))
+
+	case namingSequence:
+		return fmt.Sprintf("anim%02d_%03d.png", index/opts.Frames+1, index%opts.Frames)
+
+	default:
+		return fmt.Sprintf("test_%03d_%dx%d.png", index, width, height)
+	}
+}
+
+// groupOf splits images into Groups contiguous, nearly equal groups.
+func groupOf(opts *Options, index int) int {
+	return index * opts.Groups / opts.Count
+}
+
+// sequenceSizes makes every frame of a sequence as large as its first frame.
+func sequenceSizes(sizes [][2]int, frames int) {
+	for i := range sizes {
+		sizes[i] = sizes[i-i%frames]
+	}
+}
+
+// datasetInput returns pack input options matching the naming mode.
+func datasetInput(opts *Options) configInput {
+	switch opts.Naming {
+	case namingSeparator:
+		return configInput{GroupSeparator: opts.GroupSeparator}
+	case namingDirs:
+		return configInput{GroupDirs: true}
+	default:
+		return configInput{}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "math/rand"
+
+// Size presets.
+const (
+	presetRandom    = "random"
+	presetIdentical = "identical"
+	presetStrips    = "strips"
+	presetHugeTiny  = "huge-tiny"
+)
+
+// planSizes returns image dimensions for every image to generate.
+// Presets other than random produce known hard packing inputs:
+//
+//   - identical: Count squares of min-size, many equal rects stress tie-breaking
+//     and the size search, which keeps landing on the same candidates;
+//   - strips: alternating horizontal and vertical max-size x min-size strips,
+//     extreme aspect ratios that fragment free rectangles;
+//   - huge-tiny: one max-size square plus Count-1 images of min-size..2*min-size,
+//     so a single item dominates the atlas size.
+func planSizes(rng *rand.Rand, opts *Options) [][2]int {
+	sizes := make([][2]int, 0, opts.Count)
+
+	for i := 0; i < opts.Count; i++ {
+		var width, height int
+
+		switch opts.Preset {
+		case presetIdentical:
+			width, height = opts.MinSize, opts.MinSize
+
+		case presetStrips:
+			width, height = opts.MaxSize, opts.MinSize
+			if i%2 == 1 {
+				width, height = height, width
+			}
+
+		case presetHugeTiny:
+			if i == 0 {
+				width, height = opts.MaxSize, opts.MaxSize
+				break
+			}
+			limit := min(opts.MinSize*2, opts.MaxSize)
+			width = opts.MinSize + rng.Intn(limit-opts.MinSize+1)
+			height = opts.MinSize + rng.Intn(limit-opts.MinSize+1)
+			if !opts.AllowNonPow2 {
+				width, height = prevPowerOfTwo(width), prevPowerOfTwo(height)
+			}
+
+		default:
+			width, height = generateSize(rng, opts)
+		}
+
+		sizes = append(sizes, [2]int{width, height})
+	}
+
+	return sizes
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+)
+
+// resourceCache keeps parsed resources shared by all generated images, so
+// the font is parsed once per process instead of once per image and faces
+// of a size are built once.
+type resourceCache struct {
+	font    *opentype.Font
+	fontErr error
+	faces   map[float64]font.Face
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+// resources is the process-wide resource cache.
+var resources = &resourceCache{}
+
+// face returns the gobold face of size at 72 DPI. Faces are cached and must
+// not be closed by callers; a face is not safe for concurrent drawing.
+func (r *resourceCache) face(size float64) (font.Face, error) {
+	r.once.Do(func() {
+		r.font, r.fontErr = opentype.Parse(gobold.TTF)
+	})
+	if r.fontErr != nil {
+		return nil, r.fontErr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if face, ok := r.faces[size]; ok {
+		return face, nil
+	}
+
+	face, err := opentype.NewFace(r.font, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.faces == nil {
+		r.faces = make(map[float64]font.Face)
+	}
+	r.faces[size] = face
+
+	return face, nil
+}
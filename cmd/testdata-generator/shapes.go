@@ -0,0 +1,179 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Sprite shapes.
+const (
+	shapeBox     = "box"
+	shapeCircle  = "circle"
+	shapeRounded = "rounded"
+	shapeStar    = "star"
+	shapeText    = "text"
+	shapeMixed   = "mixed"
+)
+
+// mixedShapes is the pool used by --shape mixed.
+var mixedShapes = []string{shapeBox, shapeCircle, shapeRounded, shapeStar, shapeText}
+
+// supersample is the per-axis sample count used for anti-aliased edges.
+const supersample = 4
+
+// pickShape resolves "mixed" to a concrete shape.
+func pickShape(shape string, rng *rand.Rand) string {
+	if shape == shapeMixed {
+		return mixedShapes[rng.Intn(len(mixedShapes))]
+	}
+
+	return shape
+}
+
+// shapeMask returns per-pixel coverage for a silhouette shape, or nil for box.
+// Edges are anti-aliased so dilation and trimming see soft alpha, as in real art.
+func shapeMask(shape string, width, height int, rng *rand.Rand) *image.Alpha {
+	switch shape {
+	case shapeCircle:
+		rx, ry := float64(width)/2, float64(height)/2
+		return coverageMask(width, height, func(x, y float64) bool {
+			dx, dy := (x-rx)/rx, (y-ry)/ry
+			return dx*dx+dy*dy <= 1
+		})
+
+	case shapeRounded:
+		w, h := float64(width), float64(height)
+		r := math.Min(w, h) / 4
+		return coverageMask(width, height, func(x, y float64) bool {
+			cx := math.Max(r, math.Min(x, w-r))
+			cy := math.Max(r, math.Min(y, h-r))
+			dx, dy := x-cx, y-cy
+			return dx*dx+dy*dy <= r*r
+		})
+
+	case shapeStar:
+		poly := starPolygon(float64(width), float64(height), 5, 0.45)
+		return coverageMask(width, height, func(x, y float64) bool {
+			return pointInPolygon(poly, x, y)
+		})
+
+	case shapeText:
+		//nolint:gosec // Intn(26) is always within a byte.
+		glyph := string(rune('A' + rng.Intn(26)))
+		return textMask(width, height, glyph)
+
+	default:
+		return nil
+	}
+}
+
+// coverageMask samples inside on a supersample grid per pixel.
+func coverageMask(width, height int, inside func(x, y float64) bool) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	step := 1.0 / supersample
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			hits := 0
+			for sy := 0; sy < supersample; sy++ {
+				for sx := 0; sx < supersample; sx++ {
+					if inside(float64(x)+(float64(sx)+0.5)*step, float64(y)+(float64(sy)+0.5)*step) {
+						hits++
+					}
+				}
+			}
+			//nolint:gosec // hits <= supersample^2, result is within uint8.
+			mask.SetAlpha(x, y, color.Alpha{A: uint8(hits * 255 / (supersample * supersample))})
+		}
+	}
+
+	return mask
+}
+
+// starPolygon returns the vertices of a star inscribed in a w x h box.
+// inner is the inner radius relative to the outer one.
+func starPolygon(w, h float64, points int, inner float64) [][2]float64 {
+	cx, cy := w/2, h/2
+	poly := make([][2]float64, 0, points*2)
+	for i := 0; i < points*2; i++ {
+		scale := 1.0
+		if i%2 == 1 {
+			scale = inner
+		}
+		angle := -math.Pi/2 + float64(i)*math.Pi/float64(points)
+		poly = append(poly, [2]float64{
+			cx + math.Cos(angle)*cx*scale,
+			cy + math.Sin(angle)*cy*scale,
+		})
+	}
+
+	return poly
+}
+
+// pointInPolygon is the even-odd ray casting test.
+func pointInPolygon(poly [][2]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		xi, yi := poly[i][0], poly[i][1]
+		xj, yj := poly[j][0], poly[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// textMask renders glyph to fill most of a width x height box.
+func textMask(width, height int, glyph string) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+
+	face, err := resources.face(float64(min(width, height)) * 0.9)
+	if err != nil {
+		return mask
+	}
+
+	bounds, _ := font.BoundString(face, glyph)
+	textW := (bounds.Max.X - bounds.Min.X).Ceil()
+	textH := (bounds.Max.Y - bounds.Min.Y).Ceil()
+
+	drawer := &font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: face,
+		Dot: fixed.P(
+			(width-textW)/2-bounds.Min.X.Ceil(),
+			(height-textH)/2-bounds.Min.Y.Ceil(),
+		),
+	}
+	drawer.DrawString(glyph)
+
+	return mask
+}
+
+// applyMask scales every pixel of img by mask coverage.
+// image.RGBA is premultiplied, so all channels scale together.
+func applyMask(img *image.RGBA, mask *image.Alpha) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			a := uint32(mask.AlphaAt(x, y).A)
+			if a == 255 {
+				continue
+			}
+			c := img.RGBAAt(x, y)
+			//nolint:gosec // Scaled channels stay within uint8.
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(uint32(c.R) * a / 255),
+				G: uint8(uint32(c.G) * a / 255),
+				B: uint8(uint32(c.B) * a / 255),
+				A: uint8(uint32(c.A) * a / 255),
+			})
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package edds
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	// BlockTagCOPY marks a block stored as is.
+	BlockTagCOPY = "COPY"
+	// BlockTagLZ4 marks a block compressed as an LZ4 chunk stream.
+	BlockTagLZ4 = "LZ4 "
+	// ChunkSize is the uncompressed size of one LZ4 chunk.
+	ChunkSize = 64 * 1024
+
+	// blockEntrySize is the size of one block table entry: tag and i32 size.
+	blockEntrySize = 8
+	// chunkLastFlag marks the last chunk of an LZ4 chunk stream.
+	chunkLastFlag = 0x80
+)
+
+// blockEntry is one block table entry with the offset of its body.
+type blockEntry struct {
+	tag    string
+	offset int
+	size   int
+}
+
+// hasBlockTable reports whether payload starts with a block tag.
+// Legacy files store the base mip right after the header instead.
+func hasBlockTable(payload []byte) bool {
+	if len(payload) < 4 {
+		return false
+	}
+
+	tag := string(payload[:4])
+	return tag == BlockTagCOPY || tag == BlockTagLZ4
+}
+
+// readBlockTable parses count table entries from payload and checks that
+// every body lies within payload. Entries keep the stream order, smallest
+// mip first.
+func readBlockTable(payload []byte, count int) ([]blockEntry, error) {
+	tableSize := count * blockEntrySize
+	if len(payload) < tableSize {
+		return nil, fmt.Errorf("%w: %d entries need %d bytes, have %d", ErrInvalidBlockTable, count, tableSize, len(payload))
+	}
+
+	entries := make([]blockEntry, count)
+	offset := tableSize
+	for i := range entries {
+		raw := payload[i*blockEntrySize:]
+		tag := string(raw[:4])
+		size := int(int32(binary.LittleEndian.Uint32(raw[4:8]))) //nolint:gosec // sizes are signed i32 on disk
+
+		if tag != BlockTagCOPY && tag != BlockTagLZ4 {
+			return nil, fmt.Errorf("%w: entry %d: unknown tag %q", ErrInvalidBlockTable, i, tag)
+		}
+		if size < 0 || size > len(payload)-offset {
+			return nil, fmt.Errorf("%w: entry %d: size %d exceeds remaining %d bytes", ErrInvalidBlockTable, i, size, len(payload)-offset)
+		}
+
+		entries[i] = blockEntry{tag: tag, offset: offset, size: size}
+		offset += size
+	}
+
+	return entries, nil
+}
+
+// decodeBlock returns the raw mip data of a block body of the given size.
+func decodeBlock(tag string, body []byte, size int) ([]byte, error) {
+	switch tag {
+	case BlockTagCOPY:
+		if len(body) != size {
+			return nil, fmt.Errorf("%w: COPY block has %d bytes, want %d", ErrInvalidBlock, len(body), size)
+		}
+		return body, nil
+
+	case BlockTagLZ4:
+		// LZ4 bodies start with the u32 uncompressed size. Some legacy
+		// writers omit it, so only skip it when it matches.
+		if len(body) >= 4 && binary.LittleEndian.Uint32(body) == uint32(size) { //nolint:gosec // size <= 1 GiB
+			body = body[4:]
+		}
+		return decodeChunkStream(body, size)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown tag %q", ErrInvalidBlock, tag)
+	}
+}
+
+// decodeChunkStream inflates an LZ4 chunk stream of exactly size bytes.
+// Each chunk has a 3-byte compressed size and a flags byte; chunks share
+// a dictionary made of the previous 64 KiB of output.
+func decodeChunkStream(data []byte, size int) ([]byte, error) {
+	out := make([]byte, size)
+	pos := 0
+
+	for {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%w: truncated chunk header at output offset %d", ErrInvalidBlock, pos)
+		}
+		chunkSize := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+		flags := data[3]
+		data = data[4:]
+
+		if flags&^chunkLastFlag != 0 {
+			return nil, fmt.Errorf("%w: unknown chunk flags 0x%02x", ErrInvalidBlock, flags)
+		}
+		if chunkSize <= 0 || chunkSize > len(data) {
+			return nil, fmt.Errorf("%w: chunk size %d with %d bytes left", ErrInvalidBlock, chunkSize, len(data))
+		}
+		if pos >= size {
+			return nil, fmt.Errorf("%w: chunk past the end of a %d byte mip", ErrInvalidBlock, size)
+		}
+
+		end := min(pos+ChunkSize, size)
+		n, err := lz4.UncompressBlockWithDict(data[:chunkSize], out[pos:end], out[max(0, pos-ChunkSize):pos])
+		if err != nil {
+			return nil, fmt.Errorf("%w: LZ4: %v", ErrInvalidBlock, err)
+		}
+		pos += n
+		data = data[chunkSize:]
+
+		if flags&chunkLastFlag != 0 {
+			break
+		}
+	}
+
+	if pos != size {
+		return nil, fmt.Errorf("%w: decoded %d bytes, want %d", ErrInvalidBlock, pos, size)
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%w: %d bytes after the last chunk", ErrInvalidBlock, len(data))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,125 @@
+package edds
+
+import (
+	"encoding/binary"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	// tinyBlockSize is the mip size below which DefaultStrategy stores COPY
+	// blocks; LZ4 framing costs more than it saves on such levels.
+	tinyBlockSize = 1024
+	// maxRatioPercent is the largest compressed size, in percent of the raw
+	// size, still worth storing as LZ4 instead of COPY.
+	maxRatioPercent = 85
+)
+
+// Compressor stores the raw data of one mip level as a block body.
+type Compressor interface {
+	// Compress returns the block tag and body for data. A compressor may
+	// return BlockTagCOPY and data itself when compression does not pay off.
+	Compress(data []byte) (tag string, body []byte, err error)
+}
+
+var (
+	// CopyCompressor stores every block as is.
+	CopyCompressor Compressor = copyCompressor{}
+	// LZ4Compressor compresses blocks with the fast LZ4 encoder.
+	LZ4Compressor Compressor = lz4Compressor{}
+	// LZ4HCCompressor compresses blocks with the high compression LZ4
+	// encoder: smaller files, several times slower to write.
+	LZ4HCCompressor Compressor = lz4Compressor{hc: true}
+)
+
+// Strategy picks the compressor for one mip level. Level 0 is the base
+// level and size is the raw byte size of the level.
+type Strategy func(level, size int) Compressor
+
+// DefaultStrategy stores levels under 1 KiB as COPY and compresses the
+// others with LZ4 HC.
+var DefaultStrategy = Tiered(tinyBlockSize, LZ4HCCompressor, LZ4HCCompressor)
+
+// Uniform returns a strategy that uses c for every level.
+func Uniform(c Compressor) Strategy {
+	return func(int, int) Compressor { return c }
+}
+
+// Tiered returns a strategy that stores levels under tiny bytes as COPY,
+// compresses the base level with base and all smaller levels with rest.
+func Tiered(tiny int, base, rest Compressor) Strategy {
+	return func(level, size int) Compressor {
+		switch {
+		case size < tiny:
+			return CopyCompressor
+		case level == 0:
+			return base
+		default:
+			return rest
+		}
+	}
+}
+
+// copyCompressor implements CopyCompressor.
+type copyCompressor struct{}
+
+// Compress implements Compressor.
+func (copyCompressor) Compress(data []byte) (string, []byte, error) {
+	return BlockTagCOPY, data, nil
+}
+
+// lz4Compressor implements LZ4Compressor and LZ4HCCompressor.
+type lz4Compressor struct {
+	hc bool
+}
+
+// Compress implements Compressor. It falls back to COPY when the LZ4 body
+// would not be smaller than maxRatioPercent of data.
+func (c lz4Compressor) Compress(data []byte) (string, []byte, error) {
+	compress := new(lz4.Compressor).CompressBlock
+	if c.hc {
+		compress = new(lz4.CompressorHC).CompressBlock
+	}
+
+	body, err := compressChunks(data, compress)
+	if err != nil {
+		return "", nil, err
+	}
+	if body == nil {
+		return BlockTagCOPY, data, nil
+	}
+
+	return BlockTagLZ4, body, nil
+}
+
+// compressChunks encodes data as an LZ4 block body: the u32 raw size and a
+// chunk stream of independently compressed ChunkSize slices. It returns nil
+// when a chunk or the whole body compresses worse than maxRatioPercent.
+func compressChunks(data []byte, compress func(src, dst []byte) (int, error)) ([]byte, error) {
+	body := binary.LittleEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data))) //nolint:gosec // mip size <= 1 GiB
+	buf := make([]byte, lz4.CompressBlockBound(ChunkSize))
+
+	for pos := 0; pos < len(data); pos += ChunkSize {
+		chunk := data[pos:min(pos+ChunkSize, len(data))]
+		n, err := compress(chunk, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || n*100 > len(chunk)*maxRatioPercent {
+			return nil, nil
+		}
+
+		var flags byte
+		if pos+len(chunk) == len(data) {
+			flags = chunkLastFlag
+		}
+		body = append(body, byte(n), byte(n>>8), byte(n>>16), flags)
+		body = append(body, buf[:n]...)
+	}
+
+	if len(body)*100 > len(data)*maxRatioPercent {
+		return nil, nil
+	}
+
+	return body, nil
+}
@@ -0,0 +1,135 @@
+package edds
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/woozymasta/bcn"
+)
+
+// FormatName is the name EDDS is registered under with the image package.
+const FormatName = "edds"
+
+func init() {
+	for _, magic := range magics() {
+		image.RegisterFormat(FormatName, magic, Decode, DecodeConfig)
+	}
+}
+
+// magics returns image.RegisterFormat patterns: the DDS magic, a header of
+// any content and a COPY or LZ4 block tag where the block table starts.
+func magics() []string {
+	plain := "DDS " + strings.Repeat("?", headerSize-4)
+	dx10 := "DDS " + strings.Repeat("?", 80) + "DX10" + strings.Repeat("?", headerSize-88+dx10HeaderSize)
+
+	return []string{
+		plain + BlockTagCOPY,
+		plain + BlockTagLZ4,
+		dx10 + BlockTagCOPY,
+		dx10 + BlockTagLZ4,
+	}
+}
+
+// Decode reads an EDDS stream and returns its base mip level.
+func Decode(r io.Reader) (image.Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read EDDS payload: %w", err)
+	}
+
+	mips, err := readMipData(h, payload, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := bcn.DecodeImage(mips[0], h.width, h.height, h.format)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s mip: %w", h.format, err)
+	}
+
+	return img, nil
+}
+
+// DecodeConfig returns the base mip size of an EDDS stream without reading
+// block data.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      h.width,
+		Height:     h.height,
+	}, nil
+}
+
+// DecodeFile reads an EDDS file and returns its base mip level.
+func DecodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return Decode(f)
+}
+
+// DecodeConfigFile returns the base mip size of an EDDS file without
+// reading block data.
+func DecodeConfigFile(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return DecodeConfig(f)
+}
+
+// readMipData returns raw data of the first levels mip levels, base first.
+// Legacy files without a block table hold only the base level, either as an
+// LZ4 chunk stream or as is.
+func readMipData(h *header, payload []byte, levels int) ([][]byte, error) {
+	if !hasBlockTable(payload) {
+		size := mipDataSize(h.format, h.width, h.height)
+		if data, err := decodeChunkStream(payload, size); err == nil {
+			return [][]byte{data}, nil
+		}
+		if len(payload) == size {
+			return [][]byte{payload}, nil
+		}
+
+		return nil, fmt.Errorf("%w: no block table and payload is neither LZ4 nor a raw %d byte mip", ErrInvalidBlockTable, size)
+	}
+
+	entries, err := readBlockTable(payload, h.mipCount)
+	if err != nil {
+		return nil, err
+	}
+
+	levels = min(levels, h.mipCount)
+	mips := make([][]byte, levels)
+	for level := range mips {
+		entry := entries[h.mipCount-1-level]
+		size := mipDataSize(h.format, mipDimension(h.width, level), mipDimension(h.height, level))
+
+		data, err := decodeBlock(entry.tag, payload[entry.offset:entry.offset+entry.size], size)
+		if err != nil {
+			return nil, fmt.Errorf("mip %d: %w", level, err)
+		}
+		mips[level] = data
+	}
+
+	return mips, nil
+}
@@ -0,0 +1,172 @@
+package edds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/bcn"
+)
+
+// testImage returns 8x8 tiles of flat color, which compress to LZ4 blocks.
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x / 8 * 8 * 255 / width),  //nolint:gosec // bounded 0..255
+				G: uint8(y / 8 * 8 * 255 / height), //nolint:gosec // bounded 0..255
+				B: 128,
+				A: 255,
+			})
+		}
+	}
+
+	return img
+}
+
+// readTestEDDS returns the reference EDDS file of testImage(width, height)
+// from testdata, written by the EDDS writer used before the in-repo encoder.
+func readTestEDDS(t *testing.T, width, height int, format bcn.Format, compress bool) []byte {
+	t.Helper()
+
+	name := fmt.Sprintf("reference_%dx%d_%s", width, height, strings.ToLower(format.String()))
+	if !compress {
+		name += "_copy"
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", name+".edds"))
+	if err != nil {
+		t.Fatalf("read reference EDDS: %v", err)
+	}
+
+	return data
+}
+
+func TestDecodeRegistered(t *testing.T) {
+	t.Parallel()
+
+	src := testImage(128, 64)
+	tests := []struct {
+		name     string
+		format   bcn.Format
+		compress bool
+		exact    bool
+	}{
+		{name: "bgra8 lz4", format: bcn.FormatBGRA8, compress: true, exact: true},
+		{name: "bgra8 copy", format: bcn.FormatBGRA8, exact: true},
+		{name: "dxt1", format: bcn.FormatDXT1, compress: true},
+		{name: "dxt5", format: bcn.FormatDXT5, compress: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := readTestEDDS(t, 128, 64, tt.format, tt.compress)
+
+			cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("DecodeConfig: %v", err)
+			}
+			if name != FormatName || cfg.Width != 128 || cfg.Height != 64 {
+				t.Fatalf("DecodeConfig = %s %dx%d, want %s 128x64", name, cfg.Width, cfg.Height, FormatName)
+			}
+
+			img, name, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if name != FormatName || img.Bounds() != src.Bounds() {
+				t.Fatalf("Decode = %s %v, want %s %v", name, img.Bounds(), FormatName, src.Bounds())
+			}
+			if !tt.exact {
+				return
+			}
+			for y := 0; y < 64; y++ {
+				for x := 0; x < 128; x++ {
+					if got, want := color.NRGBAModel.Convert(img.At(x, y)), src.At(x, y); got != want {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	t.Parallel()
+
+	valid := readTestEDDS(t, 128, 128, bcn.FormatBGRA8, true)
+	patchU32 := func(off int, v uint32) []byte {
+		data := bytes.Clone(valid)
+		binary.LittleEndian.PutUint32(data[off:], v)
+		return data
+	}
+	mips := int(binary.LittleEndian.Uint32(valid[28:]))
+	lastEntry := headerSize + (mips-1)*blockEntrySize
+	if tag := string(valid[lastEntry : lastEntry+4]); tag != BlockTagLZ4 {
+		t.Fatalf("base mip stored as %q, want LZ4", tag)
+	}
+
+	tests := map[string][]byte{
+		"empty":              nil,
+		"truncated header":   valid[:64],
+		"truncated table":    valid[:headerSize+4],
+		"truncated body":     valid[:len(valid)-16],
+		"zero size":          patchU32(16, 0),
+		"huge size":          patchU32(16, 1<<30),
+		"huge mip count":     patchU32(28, 0xFFFFFFFF),
+		"negative block":     patchU32(lastEntry+4, 0xFFFFFFFF),
+		"block past eof":     patchU32(lastEntry+4, 1<<24),
+		"unknown block tag":  append(append(bytes.Clone(valid[:headerSize]), "ZZZZ"...), valid[headerSize+4:]...),
+		"lying lz4 size":     patchU32(len(valid)-int(binary.LittleEndian.Uint32(valid[lastEntry+4:])), 0x7FFFFFFF),
+		"mip count too high": patchU32(28, uint32(mips+1)), //nolint:gosec // small count
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Decode(bytes.NewReader(data)); err == nil {
+				t.Fatal("Decode succeeded on corrupt input")
+			}
+		})
+	}
+}
+
+func TestReadMips(t *testing.T) {
+	t.Parallel()
+
+	data := readTestEDDS(t, 64, 32, bcn.FormatDXT5, true)
+
+	img, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if img.Format() != bcn.FormatDXT5 {
+		t.Fatalf("Format = %s, want %s", img.Format(), bcn.FormatDXT5)
+	}
+
+	// 64x32 has a full chain of 7 levels, down to 1x1.
+	if img.MipCount() != 7 {
+		t.Fatalf("MipCount = %d, want 7", img.MipCount())
+	}
+	for i := 0; i < img.MipCount(); i++ {
+		want := image.Rect(0, 0, max(64>>i, 1), max(32>>i, 1))
+		if got := img.Mip(i).Bounds(); got != want {
+			t.Fatalf("Mip(%d) bounds = %v, want %v", i, got, want)
+		}
+	}
+	if img.Mip(img.MipCount()) != nil || img.Mip(-1) != nil {
+		t.Fatal("Mip out of range is not nil")
+	}
+	if img.Bounds() != img.Mip(0).Bounds() || img.At(5, 5) != img.Mip(0).At(5, 5) {
+		t.Fatal("Image does not match its base level")
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Package edds reads and writes Enfusion DDS (EDDS) textures as used by Arma/DayZ.
+
+EDDS stores a DDS header, a block table with one entry per mip level and the
+block bodies, both ordered from the smallest mip to the base one. A block is
+either stored as is (COPY) or compressed as an LZ4 chunk stream with a rolling
+64 KiB dictionary (LZ4).
+
+Importing the package registers the "edds" format with the standard image
+package, so image.Decode and image.DecodeConfig accept EDDS streams:
+
+	import _ "github.com/woozymasta/imageset-packer/edds"
+
+EDDS shares the "DDS " magic with plain DDS. The registered magic therefore
+also requires a COPY or LZ4 block tag right after the header, at offset 128
+(or 148 with a DX10 header). image.Decode picks the first registered format
+whose magic matches, so a format registered earlier that claims the bare
+"DDS " magic shadows this one; call Decode directly in that case.
+
+Read and ReadFile return an *Image with every stored mip level and the
+pixel format; as an image.Image it is the base level.
+
+Encode and WriteFile write a texture with a generated mip chain, EncodeMips
+writes pre-encoded mip data. The Strategy in WriteOptions picks a Compressor
+for every mip level; DefaultStrategy stores mips under 1 KiB as COPY and
+compresses the others with LZ4 HC, like the Enfusion tools.
+
+Decoding is strict about sizes: dimensions, mip counts and block sizes are
+validated against the stream before any buffer is allocated.
+*/
+package edds
@@ -0,0 +1,16 @@
+package edds
+
+import "errors"
+
+var (
+	// ErrInvalidHeader indicates a malformed or unsupported DDS header.
+	ErrInvalidHeader = errors.New("invalid EDDS header")
+	// ErrUnsupportedFormat indicates a pixel format the decoder cannot handle.
+	ErrUnsupportedFormat = errors.New("unsupported EDDS pixel format")
+	// ErrInvalidBlockTable indicates a block table that does not match the stream.
+	ErrInvalidBlockTable = errors.New("invalid EDDS block table")
+	// ErrInvalidBlock indicates a block body that cannot be decoded.
+	ErrInvalidBlock = errors.New("invalid EDDS block")
+	// ErrInvalidMipData indicates mip level data that does not fit the texture.
+	ErrInvalidMipData = errors.New("invalid EDDS mip data")
+)
@@ -0,0 +1,144 @@
+package edds
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/woozymasta/bcn"
+)
+
+const (
+	// headerSize is the size of the DDS magic and header.
+	headerSize = 4 + bcn.DDSHeaderSize
+	// dx10HeaderSize is the size of the optional DX10 header extension.
+	dx10HeaderSize = 20
+	// maxDimension bounds texture width and height. It keeps a base mip of
+	// a corrupt header from allocating more than 1 GiB of RGBA.
+	maxDimension = 16384
+)
+
+// header is the parsed DDS part of an EDDS stream.
+type header struct {
+	dds      *bcn.DDSHeader
+	format   bcn.Format
+	width    int
+	height   int
+	mipCount int
+}
+
+// readHeader reads and validates the DDS magic, header and DX10 extension.
+func readHeader(r io.Reader) (*header, error) {
+	dds, err := bcn.ReadDDSHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	dx10, err := bcn.ReadDDSHeaderDX10(r, dds)
+	if err != nil {
+		return nil, fmt.Errorf("%w: DX10: %v", ErrInvalidHeader, err)
+	}
+
+	h := &header{
+		dds:      dds,
+		format:   detectFormat(dds, dx10),
+		width:    int(dds.Width),
+		height:   int(dds.Height),
+		mipCount: 1,
+	}
+	if h.width <= 0 || h.height <= 0 || h.width > maxDimension || h.height > maxDimension {
+		return nil, fmt.Errorf("%w: size %dx%d (limit %d)", ErrInvalidHeader, dds.Width, dds.Height, maxDimension)
+	}
+	if mipDataSize(h.format, 1, 1) <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, h.format)
+	}
+
+	if dds.Caps&bcn.DDSCapsMipmap != 0 && dds.MipMapCount > 0 {
+		full := fullMipCount(h.width, h.height)
+		if dds.MipMapCount > uint32(full) { //nolint:gosec // full <= 15
+			return nil, fmt.Errorf("%w: %d mips for %dx%d (max %d)", ErrInvalidHeader, dds.MipMapCount, h.width, h.height, full)
+		}
+		h.mipCount = int(dds.MipMapCount)
+	}
+
+	return h, nil
+}
+
+// detectFormat maps DDS pixel format fields to a bcn format.
+func detectFormat(h *bcn.DDSHeader, dx10 *bcn.DDSHeaderDX10) bcn.Format {
+	if dx10 != nil {
+		switch dx10.DXGIFormat {
+		case 71:
+			return bcn.FormatDXT1
+		case 74:
+			return bcn.FormatDXT3
+		case 77:
+			return bcn.FormatDXT5
+		case 80:
+			return bcn.FormatBC4
+		case 83:
+			return bcn.FormatBC5
+		case 87:
+			return bcn.FormatBGRA8
+		case 28:
+			return bcn.FormatRGBA8
+		default:
+			return bcn.FormatUnknown
+		}
+	}
+
+	pf := h.PixelFormat
+	if pf.Flags&bcn.DDSPFFourCC != 0 {
+		switch string([]byte{byte(pf.FourCC), byte(pf.FourCC >> 8), byte(pf.FourCC >> 16), byte(pf.FourCC >> 24)}) {
+		case "DXT1":
+			return bcn.FormatDXT1
+		case "DXT2", "DXT3":
+			return bcn.FormatDXT3
+		case "DXT4", "DXT5":
+			return bcn.FormatDXT5
+		case "ATI1", "BC4U", "BC4S":
+			return bcn.FormatBC4
+		case "ATI2", "BC5U", "BC5S":
+			return bcn.FormatBC5
+		default:
+			return bcn.FormatUnknown
+		}
+	}
+
+	if pf.Flags&bcn.DDSPFRGB != 0 && pf.Flags&bcn.DDSPFAlphaPixels != 0 && pf.RGBBitCount == 32 && pf.ABitMask == 0xff000000 {
+		switch {
+		case pf.RBitMask == 0x000000ff && pf.GBitMask == 0x0000ff00 && pf.BBitMask == 0x00ff0000:
+			return bcn.FormatRGBA8
+		case pf.RBitMask == 0x00ff0000 && pf.GBitMask == 0x0000ff00 && pf.BBitMask == 0x000000ff:
+			return bcn.FormatBGRA8
+		}
+	}
+
+	return bcn.FormatUnknown
+}
+
+// fullMipCount returns the length of a complete mip chain for a size.
+func fullMipCount(width, height int) int {
+	return bits.Len(uint(max(width, height)))
+}
+
+// mipDimension returns the size of a base dimension at a mip level.
+func mipDimension(base, level int) int {
+	return max(base>>level, 1)
+}
+
+// mipDataSize returns the byte size of one mip level, or -1 for unsupported formats.
+func mipDataSize(format bcn.Format, width, height int) int {
+	blocksW := (width + 3) / 4
+	blocksH := (height + 3) / 4
+
+	switch format {
+	case bcn.FormatDXT1, bcn.FormatBC4:
+		return blocksW * blocksH * 8
+	case bcn.FormatDXT3, bcn.FormatDXT5, bcn.FormatBC5:
+		return blocksW * blocksH * 16
+	case bcn.FormatRGBA8, bcn.FormatBGRA8:
+		return width * height * 4
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,93 @@
+package edds
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+
+	"github.com/woozymasta/bcn"
+)
+
+// Image is a decoded EDDS texture with every stored mip level.
+// As an image.Image it is the base level (mip 0).
+type Image struct {
+	mips   []*image.NRGBA
+	format bcn.Format
+}
+
+// Read decodes an EDDS stream with all of its mip levels.
+// Use Decode when only the base level is needed.
+func Read(r io.Reader) (*Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read EDDS payload: %w", err)
+	}
+
+	data, err := readMipData(h, payload, h.mipCount)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{format: h.format, mips: make([]*image.NRGBA, len(data))}
+	for level, mip := range data {
+		img.mips[level], err = bcn.DecodeImage(mip, mipDimension(h.width, level), mipDimension(h.height, level), h.format)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s mip %d: %w", h.format, level, err)
+		}
+	}
+
+	return img, nil
+}
+
+// ReadFile decodes an EDDS file with all of its mip levels.
+func ReadFile(path string) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return Read(f)
+}
+
+// Format returns the stored pixel format.
+func (m *Image) Format() bcn.Format {
+	return m.format
+}
+
+// MipCount returns the number of stored mip levels, at least 1.
+func (m *Image) MipCount() int {
+	return len(m.mips)
+}
+
+// Mip returns mip level i, where 0 is the base level, or nil when the
+// texture does not store that level.
+func (m *Image) Mip(i int) *image.NRGBA {
+	if i < 0 || i >= len(m.mips) {
+		return nil
+	}
+
+	return m.mips[i]
+}
+
+// ColorModel implements image.Image.
+func (m *Image) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+// Bounds implements image.Image for the base level.
+func (m *Image) Bounds() image.Rectangle {
+	return m.mips[0].Bounds()
+}
+
+// At implements image.Image for the base level.
+func (m *Image) At(x, y int) color.Color {
+	return m.mips[0].At(x, y)
+}
@@ -0,0 +1,239 @@
+package edds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/woozymasta/bcn"
+)
+
+const (
+	// DefaultMipCap is the length of a full mip chain when WriteOptions.MipCap
+	// is 0. The Enfusion tools write at most 11 levels, a complete chain for
+	// textures up to 1024x1024.
+	DefaultMipCap = 11
+	// NoMipCap as WriteOptions.MipCap writes full chains down to 1x1.
+	NoMipCap = -1
+)
+
+// WriteOptions configures Encode. The zero value writes BGRA8 with a full
+// mip chain and DefaultStrategy.
+type WriteOptions struct {
+	// EncodeOptions are passed to the BCn encoder (quality, workers).
+	EncodeOptions *bcn.EncodeOptions
+	// Strategy picks a compressor per mip level. Nil means DefaultStrategy.
+	Strategy Strategy
+	// Format is the stored pixel format. Zero means BGRA8.
+	Format bcn.Format
+	// MaxMips limits written mip levels: 0 = full chain, 1 = base only.
+	// An explicit limit is not capped by MipCap.
+	MaxMips int
+	// MipCap caps the full chain written for MaxMips 0: 0 = DefaultMipCap,
+	// NoMipCap = down to 1x1.
+	MipCap int
+	// MinMipSize ends the chain before a level with a side below it, e.g.
+	// 4 keeps every BCn level block aligned. 0 = down to 1x1.
+	MinMipSize int
+}
+
+// Encode writes img as an EDDS stream with a generated mip chain.
+func Encode(w io.Writer, img image.Image, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	data, err := EncodeMipChain(img, opts)
+	if err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	return EncodeMips(w, writeFormat(opts), bounds.Dx(), bounds.Dy(), data, opts.Strategy)
+}
+
+// EncodeMipChain generates and encodes the mip levels Encode writes for img,
+// base level first, so other containers such as plain DDS can store the
+// same chain. Strategy is not used.
+func EncodeMipChain(img image.Image, opts *WriteOptions) ([][]byte, error) {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+	format := writeFormat(opts)
+
+	bounds := img.Bounds()
+	count := mipChainLength(bounds.Dx(), bounds.Dy(), opts)
+
+	mips := bcn.GenerateMipmaps(img, false)
+	mips = mips[:min(count, len(mips))]
+	data := make([][]byte, len(mips))
+	for level, mip := range mips {
+		encoded, _, _, err := bcn.EncodeImageWithOptions(mip, format, opts.EncodeOptions)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s mip %d: %w", format, level, err)
+		}
+		data[level] = encoded
+	}
+
+	return data, nil
+}
+
+// writeFormat returns the stored pixel format of opts.
+func writeFormat(opts *WriteOptions) bcn.Format {
+	if opts.Format == bcn.FormatUnknown {
+		return bcn.FormatBGRA8
+	}
+
+	return opts.Format
+}
+
+// mipChainLength returns the number of mip levels Encode writes for a size.
+// The base level is always written.
+func mipChainLength(width, height int, opts *WriteOptions) int {
+	count := fullMipCount(width, height)
+	switch {
+	case opts.MaxMips > 0:
+		count = min(count, opts.MaxMips)
+	case opts.MipCap == 0:
+		count = min(count, DefaultMipCap)
+	case opts.MipCap > 0:
+		count = min(count, opts.MipCap)
+	}
+	for count > 1 && min(mipDimension(width, count-1), mipDimension(height, count-1)) < opts.MinMipSize {
+		count--
+	}
+
+	return count
+}
+
+// EncodeMips writes pre-encoded mip level data, base level first, as an EDDS
+// stream. A nil strategy means DefaultStrategy.
+func EncodeMips(w io.Writer, format bcn.Format, width, height int, mips [][]byte, strategy Strategy) error {
+	if strategy == nil {
+		strategy = DefaultStrategy
+	}
+	if width <= 0 || height <= 0 || width > maxDimension || height > maxDimension {
+		return fmt.Errorf("%w: size %dx%d (limit %d)", ErrInvalidMipData, width, height, maxDimension)
+	}
+	if len(mips) == 0 || len(mips) > fullMipCount(width, height) {
+		return fmt.Errorf("%w: %d mips for %dx%d", ErrInvalidMipData, len(mips), width, height)
+	}
+
+	hdr, err := newDDSHeader(format, width, height, len(mips))
+	if err != nil {
+		return err
+	}
+
+	tags := make([]string, len(mips))
+	bodies := make([][]byte, len(mips))
+	for level, data := range mips {
+		if size := mipDataSize(format, mipDimension(width, level), mipDimension(height, level)); len(data) != size {
+			return fmt.Errorf("%w: mip %d has %d bytes, want %d", ErrInvalidMipData, level, len(data), size)
+		}
+		tags[level], bodies[level], err = strategy(level, len(data)).Compress(data)
+		if err != nil {
+			return fmt.Errorf("compress mip %d: %w", level, err)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := bcn.WriteDDSMagic(bw); err != nil {
+		return err
+	}
+	if err := bcn.WriteDDSHeader(bw, hdr); err != nil {
+		return err
+	}
+
+	// Table and bodies both run from the smallest mip to the base one.
+	var entry [blockEntrySize]byte
+	for level := len(mips) - 1; level >= 0; level-- {
+		copy(entry[:4], tags[level])
+		binary.LittleEndian.PutUint32(entry[4:], uint32(len(bodies[level]))) //nolint:gosec // body size <= 1 GiB
+		if _, err := bw.Write(entry[:]); err != nil {
+			return err
+		}
+	}
+	for level := len(mips) - 1; level >= 0; level-- {
+		if _, err := bw.Write(bodies[level]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile writes img as an EDDS file.
+func WriteFile(path string, img image.Image, opts *WriteOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Encode(f, img, opts); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// newDDSHeader returns the DDS header of an EDDS texture. Reserved1 carries
+// the "ENF1" marker the Enfusion tools write.
+func newDDSHeader(format bcn.Format, width, height, mipCount int) (*bcn.DDSHeader, error) {
+	hdr := &bcn.DDSHeader{
+		Size:        bcn.DDSHeaderSize,
+		Flags:       bcn.DDSFlagCaps | bcn.DDSFlagHeight | bcn.DDSFlagWidth | bcn.DDSFlagPixelFormat,
+		Width:       uint32(width),    //nolint:gosec // validated <= maxDimension
+		Height:      uint32(height),   //nolint:gosec // validated <= maxDimension
+		MipMapCount: uint32(mipCount), //nolint:gosec // validated <= 15
+		Depth:       1,
+		Caps:        bcn.DDSCapsTexture,
+	}
+	hdr.Reserved1[1] = fourCC("ENF1")
+	hdr.PixelFormat.Size = bcn.DDSPixelFormatSize
+	if mipCount > 1 {
+		hdr.Flags |= bcn.DDSFlagMipmapCount
+		hdr.Caps |= bcn.DDSCapsComplex | bcn.DDSCapsMipmap
+	}
+
+	compressed := map[bcn.Format]string{
+		bcn.FormatDXT1: "DXT1",
+		bcn.FormatDXT3: "DXT3",
+		bcn.FormatDXT5: "DXT5",
+		bcn.FormatBC4:  "ATI1",
+		bcn.FormatBC5:  "ATI2",
+	}
+	if code, ok := compressed[format]; ok {
+		hdr.Flags |= bcn.DDSFlagLinearSize
+		hdr.PixelFormat.Flags = bcn.DDSPFFourCC
+		hdr.PixelFormat.FourCC = fourCC(code)
+		return hdr, nil
+	}
+
+	hdr.Flags |= bcn.DDSFlagPitch
+	hdr.PitchOrLinearSize = uint32(width) * 4 //nolint:gosec // validated <= maxDimension
+	hdr.PixelFormat.Flags = bcn.DDSPFRGB | bcn.DDSPFAlphaPixels
+	hdr.PixelFormat.RGBBitCount = 32
+	hdr.PixelFormat.GBitMask = 0x0000ff00
+	hdr.PixelFormat.ABitMask = 0xff000000
+	switch format {
+	case bcn.FormatRGBA8:
+		hdr.PixelFormat.RBitMask = 0x000000ff
+		hdr.PixelFormat.BBitMask = 0x00ff0000
+	case bcn.FormatBGRA8:
+		hdr.PixelFormat.RBitMask = 0x00ff0000
+		hdr.PixelFormat.BBitMask = 0x000000ff
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	return hdr, nil
+}
+
+// fourCC packs a four character code into its little-endian DDS value.
+func fourCC(code string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(code))
+}
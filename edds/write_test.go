@@ -0,0 +1,168 @@
+package edds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/woozymasta/bcn"
+)
+
+// encodeTestEDDS encodes img with opts and returns the stream bytes.
+func encodeTestEDDS(t *testing.T, opts *WriteOptions) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, testImage(128, 64), opts); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// blockTags returns the block table tags of an encoded stream, base mip first.
+func blockTags(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	count := int(binary.LittleEndian.Uint32(data[28:]))
+	tags := make([]string, count)
+	for i := range tags {
+		entry := headerSize + (count-1-i)*blockEntrySize
+		tags[i] = string(data[entry : entry+4])
+	}
+
+	return tags
+}
+
+func TestEncodeStrategies(t *testing.T) {
+	t.Parallel()
+
+	src := testImage(128, 64)
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []string // block tags, base first; empty means any
+	}{
+		{name: "default", want: []string{"LZ4 ", "LZ4 ", "LZ4 ", "COPY", "COPY", "COPY", "COPY", "COPY"}},
+		{name: "copy", strategy: Uniform(CopyCompressor), want: []string{"COPY", "COPY", "COPY", "COPY", "COPY", "COPY", "COPY", "COPY"}},
+		{name: "lz4", strategy: Uniform(LZ4Compressor), want: []string{"LZ4 ", "LZ4 ", "LZ4 ", "", "", "", "", ""}},
+		{name: "tiered", strategy: Tiered(1<<20, LZ4HCCompressor, LZ4Compressor), want: []string{"COPY", "COPY", "COPY", "COPY", "COPY", "COPY", "COPY", "COPY"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := encodeTestEDDS(t, &WriteOptions{Strategy: tt.strategy})
+			tags := blockTags(t, data)
+			if len(tags) != len(tt.want) {
+				t.Fatalf("mip count = %d, want %d", len(tags), len(tt.want))
+			}
+			for level := range tags {
+				if tt.want[level] != "" && tags[level] != tt.want[level] {
+					t.Fatalf("mip %d tag = %q, want %q", level, tags[level], tt.want[level])
+				}
+			}
+
+			img, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			mips := bcn.GenerateMipmaps(src, false)
+			for level := 0; level < img.MipCount(); level++ {
+				if !bytes.Equal(img.Mip(level).Pix, mips[level].Pix) {
+					t.Fatalf("mip %d pixels differ after round trip", level)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeMatchesReference(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []bcn.Format{bcn.FormatBGRA8, bcn.FormatDXT1, bcn.FormatDXT5} {
+		want := readTestEDDS(t, 128, 64, format, true)
+		got := encodeTestEDDS(t, &WriteOptions{Format: format})
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s: Encode differs from the reference writer (%d vs %d bytes)", format, len(got), len(want))
+		}
+	}
+}
+
+func TestEncodeLimits(t *testing.T) {
+	t.Parallel()
+
+	data := encodeTestEDDS(t, &WriteOptions{Format: bcn.FormatDXT5, MaxMips: 2})
+	img, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if img.Format() != bcn.FormatDXT5 || img.MipCount() != 2 {
+		t.Fatalf("Read = %s with %d mips, want %s with 2", img.Format(), img.MipCount(), bcn.FormatDXT5)
+	}
+
+	err = EncodeMips(&bytes.Buffer{}, bcn.FormatBGRA8, 4, 4, [][]byte{make([]byte, 15)}, nil)
+	if !errors.Is(err, ErrInvalidMipData) {
+		t.Fatalf("EncodeMips with short mip = %v, want ErrInvalidMipData", err)
+	}
+}
+
+func TestMipChainLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		width, height int
+		opts          WriteOptions
+		want          int
+	}{
+		{name: "full", width: 128, height: 64, want: 8},
+		{name: "default cap", width: 4096, height: 4096, want: DefaultMipCap},
+		{name: "custom cap", width: 4096, height: 4096, opts: WriteOptions{MipCap: 12}, want: 12},
+		{name: "no cap", width: 4096, height: 2048, opts: WriteOptions{MipCap: NoMipCap}, want: 13},
+		{name: "explicit above cap", width: 4096, height: 4096, opts: WriteOptions{MaxMips: 13}, want: 13},
+		{name: "explicit above chain", width: 16, height: 16, opts: WriteOptions{MaxMips: 13}, want: 5},
+		{name: "max mips", width: 128, height: 64, opts: WriteOptions{MaxMips: 3}, want: 3},
+		{name: "floor 4", width: 128, height: 64, opts: WriteOptions{MinMipSize: 4}, want: 5},
+		{name: "floor 4 square", width: 64, height: 64, opts: WriteOptions{MinMipSize: 4}, want: 5},
+		{name: "floor above base", width: 16, height: 8, opts: WriteOptions{MinMipSize: 32}, want: 1},
+		{name: "floor and max", width: 128, height: 64, opts: WriteOptions{MinMipSize: 4, MaxMips: 2}, want: 2},
+	}
+
+	for _, tt := range tests {
+		if got := mipChainLength(tt.width, tt.height, &tt.opts); got != tt.want {
+			t.Fatalf("%s: mipChainLength(%d, %d) = %d, want %d", tt.name, tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeHeaderMatchesBlocks(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []WriteOptions{{}, {MaxMips: 1}, {MipCap: 3}, {MipCap: NoMipCap, MinMipSize: 8}} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, testImage(2048, 16), &opts); err != nil {
+			t.Fatalf("%+v: Encode: %v", opts, err)
+		}
+		data := buf.Bytes()
+
+		count := int(binary.LittleEndian.Uint32(data[28:]))
+		if want := mipChainLength(2048, 16, &opts); count != want {
+			t.Fatalf("%+v: header MipMapCount = %d, want %d", opts, count, want)
+		}
+		if flagged := binary.LittleEndian.Uint32(data[108:])&bcn.DDSCapsMipmap != 0; flagged != (count > 1) {
+			t.Fatalf("%+v: mipmap caps flag = %v with %d mips", opts, flagged, count)
+		}
+
+		// The table must describe exactly the rest of the stream.
+		end := headerSize + count*blockEntrySize
+		for i := 0; i < count; i++ {
+			end += int(binary.LittleEndian.Uint32(data[headerSize+i*blockEntrySize+4:]))
+		}
+		if end != len(data) {
+			t.Fatalf("%+v: %d mips describe %d bytes, stream has %d", opts, count, end, len(data))
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,51 +20,194 @@ type CmdBuild struct {
 		Path string `positional-arg-name:"path" description:"Path to config file or directory (default: ./.imageset-packer.yaml)"`
 	} `positional-args:"yes"`
 
-	Only []string `short:"p" long:"project" description:"Build only selected project names (repeatable)" yaml:"-"`
+	Only     []string `short:"p" long:"project" description:"Build only selected project names (repeatable)" yaml:"-"`
+	CacheDir string   `long:"cache-dir" description:"Directory for .imagehash cache files of all projects (overrides project cache_dir)" yaml:"-"`
+	Graph    string   `long:"graph" description:"Print a graph of input directories, projects and outputs without building" choice:"dot" choice:"mermaid" yaml:"-"`
+	Warnings string   `long:"warnings-json" description:"Also write warnings of all projects as JSON records with categories to this file" yaml:"-"`
+	List     bool     `short:"l" long:"list" description:"List projects with resolved paths, effective settings and cache status without building" yaml:"-"`
+
+	// warnings collects warnings of all projects.
+	warnings *warningLog
 }
 
 // Execute runs the build command.
 func (c *CmdBuild) Execute(args []string) error {
-	return runBuild(c)
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the build command until ctx is canceled.
+func (c *CmdBuild) ExecuteContext(ctx context.Context, args []string) error {
+	return runBuild(ctx, c)
 }
 
-func runBuild(opts *CmdBuild) error {
-	configPath, err := resolveConfigPath(opts.Args.Path)
+func runBuild(ctx context.Context, opts *CmdBuild) error {
+	selected, err := loadProjects(opts.Args.Path, opts.Only, opts.CacheDir)
 	if err != nil {
 		return err
 	}
 
+	if opts.List {
+		return listProjects(ctx, selected)
+	}
+	if opts.Graph != "" {
+		graph, err := buildProjectGraph(selected)
+		if err != nil {
+			return err
+		}
+		if opts.Graph == "mermaid" {
+			graph.writeMermaid(os.Stdout)
+		} else {
+			graph.writeDOT(os.Stdout)
+		}
+
+		return nil
+	}
+
+	if err := checkWritable(ctx, opts.Warnings); err != nil {
+		return err
+	}
+
+	opts.warnings = &warningLog{}
+	err = buildProjects(ctx, selected, opts.warnings)
+	if opts.Warnings != "" {
+		if writeErr := opts.warnings.WriteJSON(opts.Warnings); writeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write warnings: %w", writeErr)
+		}
+	}
+
+	return err
+}
+
+// buildProjects packs projects in order and records their warnings.
+func buildProjects(ctx context.Context, projects []CmdPack, warnings *warningLog) error {
+	for _, cfg := range projects {
+		if err := checkInterrupted(ctx); err != nil {
+			return err
+		}
+		cfg.warnings = warnings
+		if err := runPack(ctx, &cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadProjects reads the build config and returns selected projects with
+// defaults applied, paths resolved and the optional cache dir override set.
+func loadProjects(path string, only []string, cacheDir string) ([]CmdPack, error) {
+	configPath, err := resolveConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return fmt.Errorf("read config: %w", err)
+		return nil, fmt.Errorf("read config: %w", err)
 	}
 
 	projects, err := parsePackProjects(data)
 	if err != nil {
-		return fmt.Errorf("parse config: %w", err)
+		return nil, fmt.Errorf("parse config: %w", err)
 	}
 	if len(projects) == 0 {
-		return fmt.Errorf("no projects found in %q", configPath)
+		return nil, fmt.Errorf("no projects found in %q", configPath)
 	}
 
 	baseDir := filepath.Dir(configPath)
-	selected, err := filterProjects(projects, opts.Only, baseDir)
+	selected, err := filterProjects(projects, only, baseDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(selected) == 0 {
-		return fmt.Errorf("no projects selected")
+		return nil, fmt.Errorf("no projects selected")
 	}
 
-	for _, cfg := range selected {
-		if err := runPack(&cfg); err != nil {
+	if cacheDir != "" {
+		for i := range selected {
+			selected[i].Cache = cacheDir
+		}
+	}
+
+	return selected, nil
+}
+
+// listProjects prints resolved project paths, effective settings and
+// whether the cache considers outputs up to date. Nothing is written.
+func listProjects(ctx context.Context, projects []CmdPack) error {
+	for i := range projects {
+		cfg := &projects[i]
+
+		name, err := resolveProjectName(cfg)
+		if err != nil {
+			return err
+		}
+		outputs, err := resolvePackOutputs(cfg)
+		if err != nil {
+			return err
+		}
+		cachePath, err := resolveCachePath(cfg.Cache, outputs.Dir, outputs.Name)
+		if err != nil {
 			return err
 		}
+
+		var settings bytes.Buffer
+		enc := yaml.NewEncoder(&settings)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return fmt.Errorf("encode settings: %w", err)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("project:  %s\n", name)
+		fmt.Printf("input:    %s\n", cfg.Args.Input)
+		fmt.Printf("imageset: %s\n", outputs.Imageset)
+		if !cfg.LayoutOnly {
+			fmt.Printf("edds:     %s\n", outputs.EDDS)
+		}
+		if cfg.LayoutOnly || cfg.LayoutJSON {
+			fmt.Printf("layout:   %s\n", outputs.Layout)
+		}
+		fmt.Printf("cache:    %s\n", cachePath)
+		fmt.Printf("status:   %s\n", projectStatus(ctx, cfg, outputs, cachePath))
+		if report := resolvePackProfile(&cfg.Packing).String(); report != "" {
+			fmt.Printf("profile:  %s %s\n", cfg.Packing.OutputFormat, report)
+		}
+		fmt.Println("settings:")
+		for _, line := range strings.Split(strings.TrimRight(settings.String(), "\n"), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
 	}
 
 	return nil
 }
 
+// projectStatus reports whether project outputs are up to date per the cache.
+func projectStatus(ctx context.Context, cfg *CmdPack, outputs packOutputs, cachePath string) string {
+	files, err := discoverImageFiles(cfg)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	inputsHash, _, err := computeInputsHash(ctx, cfg, files, knownInputHashes(cfg, cachePath))
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	settingsHash, err := computeSettingsHash(cfg)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	reason := staleReason(cachePath, newCacheRecord(inputsHash, settingsHash, nil), outputs.Dir, outputs.Imageset, outputs.Atlas)
+	if reason == "" {
+		return fmt.Sprintf("up-to-date (%d inputs)", len(files))
+	}
+
+	return fmt.Sprintf("outdated: %s (%d inputs)", reason, len(files))
+}
+
 // resolveConfigPath resolves the path to the config file.
 func resolveConfigPath(arg string) (string, error) {
 	if strings.TrimSpace(arg) == "" {
@@ -120,7 +265,9 @@ func filterProjects(projects []CmdPack, only []string, baseDir string) ([]CmdPac
 		if err := defaults.Set(&projects[i]); err != nil {
 			return nil, fmt.Errorf("apply defaults: %w", err)
 		}
-		normalizeProjectPaths(&projects[i], baseDir)
+		if err := normalizeProjectPaths(&projects[i], baseDir); err != nil {
+			return nil, fmt.Errorf("project %d: %w", i+1, err)
+		}
 	}
 	if len(only) == 0 {
 		return projects, nil
@@ -170,10 +317,29 @@ func resolveProjectName(cfg *CmdPack) (string, error) {
 	return filepath.Base(absInput), nil
 }
 
-// normalizeProjectPaths normalizes the project paths.
-func normalizeProjectPaths(cfg *CmdPack, baseDir string) {
-	cfg.Args.Input = resolveRelativePath(baseDir, cfg.Args.Input)
-	cfg.Args.Output = resolveRelativePath(baseDir, cfg.Args.Output)
+// normalizeProjectPaths converts project paths to platform separators and
+// resolves relative ones against the config directory, so a config written
+// on Windows builds the same on Linux.
+func normalizeProjectPaths(cfg *CmdPack, baseDir string) error {
+	for _, p := range []*string{
+		&cfg.Args.Input,
+		&cfg.Args.Output,
+		&cfg.Cache,
+		&cfg.Packing.FreezeFrom,
+		&cfg.Input.RenameMap,
+		&cfg.VanillaNames,
+	} {
+		normalized, err := normalizeConfigPath(*p)
+		if err != nil {
+			return err
+		}
+		*p = resolveRelativePath(baseDir, normalized)
+	}
+	if err := validateTexturePath(cfg.Path); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // resolveRelativePath resolves the relative path to the project.
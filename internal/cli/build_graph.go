@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// projectGraph describes projects, the directories feeding them and outputs.
+type projectGraph struct {
+	// dirs maps input directory path to node ID.
+	dirs map[string]string
+	// projects lists project nodes in config order.
+	projects []graphProject
+}
+
+// graphProject is one project node with its edges.
+type graphProject struct {
+	id      string
+	name    string
+	dirs    []string
+	outputs []string
+}
+
+// buildProjectGraph collects directories that actually contribute images
+// to each project, so shared and nested art folders become shared nodes.
+func buildProjectGraph(projects []CmdPack) (*projectGraph, error) {
+	g := &projectGraph{dirs: make(map[string]string)}
+
+	for i := range projects {
+		cfg := &projects[i]
+
+		name, err := resolveProjectName(cfg)
+		if err != nil {
+			return nil, err
+		}
+		outputs, err := resolvePackOutputs(cfg)
+		if err != nil {
+			return nil, err
+		}
+		files, err := discoverImageFiles(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("project %q: %w", name, err)
+		}
+
+		dirSet := make(map[string]struct{})
+		for _, f := range files {
+			dirSet[filepath.Clean(filepath.Dir(f.path))] = struct{}{}
+		}
+
+		dirs := make([]string, 0, len(dirSet))
+		for dir := range dirSet {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		for _, dir := range dirs {
+			if _, ok := g.dirs[dir]; !ok {
+				g.dirs[dir] = fmt.Sprintf("d%d", len(g.dirs))
+			}
+		}
+
+		g.projects = append(g.projects, graphProject{
+			id:      fmt.Sprintf("p%d", i),
+			name:    name,
+			dirs:    dirs,
+			outputs: []string{filepath.Clean(outputs.Imageset), filepath.Clean(outputs.Atlas)},
+		})
+	}
+
+	return g, nil
+}
+
+// sortedDirs returns directory paths in stable order.
+func (g *projectGraph) sortedDirs() []string {
+	dirs := make([]string, 0, len(g.dirs))
+	for dir := range g.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// writeDOT renders the graph in Graphviz DOT format.
+func (g *projectGraph) writeDOT(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "digraph imageset_packer {")
+	_, _ = fmt.Fprintln(w, "  rankdir=LR;")
+
+	for _, dir := range g.sortedDirs() {
+		_, _ = fmt.Fprintf(w, "  %s [label=%q, shape=folder];\n", g.dirs[dir], dir)
+	}
+
+	for _, p := range g.projects {
+		_, _ = fmt.Fprintf(w, "  %s [label=%q, shape=box, style=bold];\n", p.id, p.name)
+		for _, dir := range p.dirs {
+			_, _ = fmt.Fprintf(w, "  %s -> %s;\n", g.dirs[dir], p.id)
+		}
+		for i, out := range p.outputs {
+			outID := fmt.Sprintf("%so%d", p.id, i)
+			_, _ = fmt.Fprintf(w, "  %s [label=%q, shape=note];\n", outID, out)
+			_, _ = fmt.Fprintf(w, "  %s -> %s;\n", p.id, outID)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w, "}")
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart.
+func (g *projectGraph) writeMermaid(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "flowchart LR")
+
+	for _, dir := range g.sortedDirs() {
+		_, _ = fmt.Fprintf(w, "  %s[(\"%s\")]\n", g.dirs[dir], mermaidEscape(dir))
+	}
+
+	for _, p := range g.projects {
+		_, _ = fmt.Fprintf(w, "  %s[[\"%s\"]]\n", p.id, mermaidEscape(p.name))
+		for _, dir := range p.dirs {
+			_, _ = fmt.Fprintf(w, "  %s --> %s\n", g.dirs[dir], p.id)
+		}
+		for i, out := range p.outputs {
+			outID := fmt.Sprintf("%so%d", p.id, i)
+			_, _ = fmt.Fprintf(w, "  %s[\"%s\"]\n", outID, mermaidEscape(out))
+			_, _ = fmt.Fprintf(w, "  %s --> %s\n", p.id, outID)
+		}
+	}
+}
+
+// mermaidEscape escapes characters that break quoted Mermaid labels.
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(filepath.ToSlash(s), `"`, "#quot;")
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CmdClean removes generated artifacts of build projects.
+type CmdClean struct {
+	Args struct {
+		Path string `positional-arg-name:"path" description:"Path to config file or directory (default: ./.imageset-packer.yaml)"`
+	} `positional-args:"yes"`
+
+	CacheDir string   `long:"cache-dir" description:"Directory with .imagehash cache files (overrides project cache_dir)"`
+	Only     []string `short:"p" long:"project" description:"Clean only selected project names (repeatable)"`
+	DryRun   bool     `short:"n" long:"dry-run" description:"Print files that would be removed without removing them"`
+}
+
+// Execute runs the clean command.
+func (c *CmdClean) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the clean command.
+func (c *CmdClean) ExecuteContext(ctx context.Context, args []string) error {
+	return runClean(ctx, c)
+}
+
+func runClean(ctx context.Context, opts *CmdClean) error {
+	selected, err := loadProjects(opts.Args.Path, opts.Only, opts.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, cfg := range selected {
+		files, err := projectArtifacts(&cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range files {
+			if _, err := os.Stat(path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return fmt.Errorf("stat %q: %w", path, err)
+			}
+
+			if opts.DryRun {
+				fmt.Printf("Would remove %s\n", path)
+				removed++
+				continue
+			}
+
+			if err := checkWritable(ctx, path); err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove %q: %w", path, err)
+			}
+			fmt.Printf("Removed %s\n", path)
+			removed++
+		}
+	}
+
+	if opts.DryRun {
+		fmt.Printf("%d file(s) would be removed\n", removed)
+	} else {
+		fmt.Printf("%d file(s) removed\n", removed)
+	}
+
+	return nil
+}
+
+// projectArtifacts lists generated files of a project.
+// Outputs recorded in the project cache take precedence; without a versioned
+// cache the deterministic .imageset/.edds/.tiles.json/.layout.json names are
+// used. Placement failure dumps are never recorded and always listed.
+// No wildcards are expanded, so unrelated files in shared output
+// directories are left intact.
+func projectArtifacts(cfg *CmdPack) ([]string, error) {
+	outputs, err := resolvePackOutputs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath, err := resolveCachePath(cfg.Cache, outputs.Dir, outputs.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// A corrupt cache is removed as well; fall back to the known output names.
+	rec, err := readCache(cachePath)
+	if err != nil {
+		rec = nil
+	}
+
+	var files []string
+	if rec != nil && !rec.Legacy && len(rec.Outputs) > 0 {
+		for _, out := range rec.Outputs {
+			files = append(files, filepath.Join(outputs.Dir, filepath.Base(out.Name)))
+		}
+	} else {
+		files = append(files, outputs.Imageset, outputs.EDDS, outputs.Tiles, outputs.Layout)
+	}
+
+	text, image := placementDumpPaths(outputs.Dir, outputs.Name)
+
+	return append(files, text, image, cachePath), nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProjectArtifactsPlacementDump(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &CmdPack{Name: "icons"}
+	cfg.Args.Input = filepath.Join(dir, "icons")
+	cfg.Args.Output = dir
+
+	files, err := projectArtifacts(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"icons.imageset", "icons.edds", "icons.placement.txt", "icons.placement.png"} {
+		if !slices.Contains(files, filepath.Join(dir, name)) {
+			t.Fatalf("projectArtifacts = %q, want %s", files, name)
+		}
+	}
+
+	// Dumps are listed also when the cache records the outputs.
+	rec := &cacheRecord{Outputs: []cacheOutput{{Name: "icons.imageset"}}}
+	if err := writeCache(filepath.Join(dir, "icons.imagehash"), rec); err != nil {
+		t.Fatal(err)
+	}
+	if files, err = projectArtifacts(cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"icons.imageset", "icons.placement.txt", "icons.placement.png", "icons.imagehash"}
+	for i := range want {
+		want[i] = filepath.Join(dir, want[i])
+	}
+	if !slices.Equal(files, want) {
+		t.Fatalf("projectArtifacts with cache = %q, want %q", files, want)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// alphaKeyAuto is the --alpha-key value that detects the key per file.
+const alphaKeyAuto = "auto"
+
+// parseColorKey parses --alpha-key; nil means alphaKeyAuto.
+func parseColorKey(s string) (*imageio.RGB, error) {
+	if strings.EqualFold(strings.TrimSpace(s), alphaKeyAuto) {
+		return nil, nil
+	}
+
+	key, err := imageio.ParseHexRGB(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --alpha-key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// parseColorKeyOptions parses --alpha-key-mode and --alpha-key-color. The
+// replace mode requires a color, which other modes do not use.
+func parseColorKeyOptions(mode, color string) (*imageio.ColorKeyOptions, error) {
+	m, err := imageio.ParseColorKeyMode(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --alpha-key-mode: %w", err)
+	}
+
+	opts := &imageio.ColorKeyOptions{Mode: m}
+	switch {
+	case m == imageio.ColorKeyReplace && color == "":
+		return nil, fmt.Errorf("--alpha-key-mode replace requires --alpha-key-color")
+	case m != imageio.ColorKeyReplace && color != "":
+		return nil, fmt.Errorf("--alpha-key-color is used only with --alpha-key-mode replace")
+	case color != "":
+		if opts.Replacement, err = imageio.ParseHexRGB(color); err != nil {
+			return nil, fmt.Errorf("invalid --alpha-key-color: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// describeDetectedKeys summarizes auto-detected keys by color with their
+// file counts; long lists are cut by listNames.
+func describeDetectedKeys(detected map[imageio.RGB]int) string {
+	if len(detected) == 0 {
+		return "no flat border color found, no file keyed"
+	}
+
+	parts := make([]string, 0, len(detected))
+	files := 0
+	for key, n := range detected {
+		parts = append(parts, fmt.Sprintf("%s (%d)", key, n))
+		files += n
+	}
+
+	return fmt.Sprintf("keyed %d file(s) on %s", files, listNames(parts))
+}
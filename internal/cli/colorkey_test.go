@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+func TestParseColorKey(t *testing.T) {
+	t.Parallel()
+
+	if key, err := parseColorKey("Auto"); err != nil || key != nil {
+		t.Fatalf(`parseColorKey("Auto") = %v, %v, want auto`, key, err)
+	}
+	if key, err := parseColorKey("#FF00FF"); err != nil || key == nil || *key != (imageio.RGB{R: 0xff, B: 0xff}) {
+		t.Fatalf(`parseColorKey("#FF00FF") = %v, %v`, key, err)
+	}
+	if _, err := parseColorKey("magenta"); err == nil {
+		t.Fatal(`parseColorKey("magenta") succeeded`)
+	}
+}
+
+func TestDescribeDetectedKeys(t *testing.T) {
+	t.Parallel()
+
+	got := describeDetectedKeys(map[imageio.RGB]int{{}: 2, {R: 0xff, B: 0xff}: 5, {G: 0xff}: 2})
+	if want := "keyed 9 file(s) on 000000 (2), 00ff00 (2), ff00ff (5)"; got != want {
+		t.Fatalf("describeDetectedKeys = %q, want %q", got, want)
+	}
+	if got := describeDetectedKeys(nil); got != "no flat border color found, no file keyed" {
+		t.Fatalf("describeDetectedKeys(nil) = %q", got)
+	}
+}
@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/creasty/defaults"
+	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/edds"
+	"github.com/woozymasta/imageset-packer/internal/vars"
+)
+
+// Conformance stages where a round-trip can fail.
+const (
+	stageParse   = "parse"
+	stageTexture = "texture"
+	stageUnpack  = "unpack"
+	stagePack    = "pack"
+	stageCompare = "compare"
+)
+
+// conformanceMaxSize is the atlas size limit for repacking. Repacked sprites
+// are laid out with a different heuristic than the original, so they may
+// need more room than the original atlas.
+const conformanceMaxSize = 16384
+
+// CmdConformance round-trips existing imagesets to find files the tool cannot handle.
+type CmdConformance struct {
+	Args struct {
+		Dir string `positional-arg-name:"dir" description:"Directory with .imageset + .edds pairs (searched recursively)" required:"yes"`
+	} `positional-args:"yes" required:"yes"`
+
+	Report  string `short:"o" long:"report" description:"Path of the JSON compatibility report" default:"conformance.json"`
+	WorkDir string `long:"work-dir" description:"Keep intermediate files in this directory (default: temporary, removed)"`
+}
+
+// conformanceReport is the machine-readable result of a conformance run.
+type conformanceReport struct {
+	ToolVersion string              `json:"tool_version"`
+	Root        string              `json:"root"`
+	Results     []conformanceResult `json:"results"`
+	Total       int                 `json:"total"`
+	Passed      int                 `json:"passed"`
+	Failed      int                 `json:"failed"`
+}
+
+// conformanceResult is the round-trip outcome of one imageset.
+type conformanceResult struct {
+	Imageset string `json:"imageset"`
+	EDDS     string `json:"edds,omitempty"`
+	Status   string `json:"status"`
+	Stage    string `json:"stage,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Renamed lists names changed by imageset name normalization.
+	Renamed []string `json:"renamed,omitempty"`
+	// Mismatched lists images missing from the repacked set or differing in size or pixels.
+	Mismatched []string `json:"mismatched,omitempty"`
+	Images     int      `json:"images"`
+}
+
+// Execute runs the conformance command.
+func (c *CmdConformance) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the conformance command until ctx is canceled.
+func (c *CmdConformance) ExecuteContext(ctx context.Context, args []string) error {
+	return runConformance(ctx, c)
+}
+
+// runConformance unpacks every imageset under the root directory, packs the
+// sprites again and compares names, sizes and pixels with the original.
+func runConformance(ctx context.Context, opts *CmdConformance) error {
+	root, err := filepath.Abs(opts.Args.Dir)
+	if err != nil {
+		return err
+	}
+
+	paths, err := findImagesets(root)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no .imageset files found in %q", root)
+	}
+
+	if err := checkWritable(ctx, opts.WorkDir, opts.Report); err != nil {
+		return err
+	}
+
+	workDir := opts.WorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "imageset-conformance-*")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(workDir) }()
+	}
+
+	report := conformanceReport{
+		ToolVersion: vars.Version,
+		Root:        root,
+		Total:       len(paths),
+	}
+
+	for i, path := range paths {
+		if err := checkInterrupted(ctx); err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		result := roundTripImageset(ctx, path, filepath.Join(workDir, fmt.Sprintf("%04d", i)))
+		result.Imageset = filepath.ToSlash(rel)
+		if result.EDDS != "" {
+			if relEDDS, err := filepath.Rel(root, result.EDDS); err == nil {
+				result.EDDS = filepath.ToSlash(relEDDS)
+			}
+		}
+		if err := checkInterrupted(ctx); err != nil {
+			return err
+		}
+
+		if result.Status == "ok" {
+			report.Passed++
+		} else {
+			report.Failed++
+			fmt.Fprintf(os.Stderr, "FAIL %s (%s): %s\n", result.Imageset, result.Stage, result.Error)
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(opts.Report, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Printf("Round-tripped %d of %d imagesets; report: %s\n", report.Passed, report.Total, opts.Report)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d imagesets failed to round-trip", report.Failed, report.Total)
+	}
+
+	return nil
+}
+
+// findImagesets returns sorted .imageset paths under root.
+func findImagesets(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".imageset") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %q: %w", root, err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// roundTripImageset runs unpack and pack for one imageset inside workDir.
+func roundTripImageset(ctx context.Context, path, workDir string) conformanceResult {
+	fail := func(r conformanceResult, stage string, err error) conformanceResult {
+		r.Status, r.Stage, r.Error = "fail", stage, err.Error()
+		return r
+	}
+
+	var result conformanceResult
+
+	doc, err := imageset.ParseFile(path)
+	if err != nil {
+		return fail(result, stageParse, err)
+	}
+	result.Images = countImages(doc)
+
+	eddsPath, err := resolveTexturePath(path, doc)
+	if err != nil {
+		return fail(result, stageTexture, err)
+	}
+	result.EDDS = eddsPath
+
+	unpackDir := filepath.Join(workDir, "sprites")
+	unpack := &CmdUnpack{OutFormat: "png", OutputDir: unpackDir, Overwrite: true, KeepGroups: true}
+	unpack.Args.ImageSetPath = path
+	unpack.Args.EDDSPath = eddsPath
+	if err := runUnpack(ctx, unpack); err != nil {
+		return fail(result, stageUnpack, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var pack CmdPack
+	if err := defaults.Set(&pack); err != nil {
+		return fail(result, stagePack, err)
+	}
+	pack.Name = name
+	pack.Force = true
+	pack.Args.Input = unpackDir
+	pack.Args.Output = filepath.Join(workDir, "out")
+	pack.Input.GroupDirs = true
+	pack.Packing.MaxSize = conformanceMaxSize
+	pack.Packing.Mipmaps = 1
+	if err := runPack(ctx, &pack); err != nil {
+		return fail(result, stagePack, err)
+	}
+
+	outputs, err := resolvePackOutputs(&pack)
+	if err != nil {
+		return fail(result, stagePack, err)
+	}
+	result.Renamed, result.Mismatched, err = compareRoundTrip(doc, eddsPath, outputs)
+	if err != nil {
+		return fail(result, stageCompare, err)
+	}
+	if len(result.Mismatched) > 0 {
+		return fail(result, stageCompare, fmt.Errorf("%d of %d images differ after round-trip", len(result.Mismatched), result.Images))
+	}
+
+	result.Status = "ok"
+	return result
+}
+
+// resolveTexturePath finds the atlas of an imageset next to it: by the base
+// name of its texture reference first, then by the imageset base name.
+// Engine texture paths are relative to the game data root, so they rarely
+// resolve as is.
+func resolveTexturePath(imagesetPath string, doc *imageset.Document) (string, error) {
+	dir := filepath.Dir(imagesetPath)
+	base := strings.TrimSuffix(filepath.Base(imagesetPath), filepath.Ext(imagesetPath))
+
+	var candidates []string
+	for _, tex := range doc.Textures {
+		texBase := filepath.Base(filepath.FromSlash(strings.ReplaceAll(tex.Path, `\`, "/")))
+		texBase = strings.TrimSuffix(texBase, filepath.Ext(texBase))
+		candidates = append(candidates, filepath.Join(dir, texBase+".edds"))
+	}
+	candidates = append(candidates, filepath.Join(dir, base+".edds"))
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no .edds found next to imageset (tried %s)", strings.Join(candidates, ", "))
+}
+
+// countImages returns the number of image definitions in doc.
+func countImages(doc *imageset.Document) int {
+	n := len(doc.Images)
+	for _, g := range doc.Groups {
+		n += len(g.Images)
+	}
+
+	return n
+}
+
+// compareRoundTrip checks that every original image is present in the
+// repacked set with the same size and pixels. Names are matched after
+// imageset name normalization, which pack applies on write.
+func compareRoundTrip(orig *imageset.Document, origEDDS string, outputs packOutputs) (renamed, mismatched []string, err error) {
+	repacked, err := imageset.ParseFile(outputs.Imageset)
+	if err != nil {
+		return nil, nil, err
+	}
+	origAtlas, err := edds.DecodeFile(origEDDS)
+	if err != nil {
+		return nil, nil, err
+	}
+	repackedAtlas, err := edds.DecodeFile(outputs.EDDS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byName := make(map[string]imageset.Image)
+	for _, def := range repacked.Images {
+		byName[def.Name] = def
+	}
+	for _, g := range repacked.Groups {
+		for _, def := range g.Images {
+			byName[def.Name] = def
+		}
+	}
+
+	sx, sy := atlasScale(orig, origAtlas.Bounds())
+	check := func(def imageset.Image) {
+		name := imageset.NormalizeName(def.Name, false)
+		if name != def.Name {
+			renamed = append(renamed, def.Name+" -> "+name)
+		}
+
+		got, ok := byName[name]
+		origRect := image.Rect(def.Pos.X*sx, def.Pos.Y*sy, (def.Pos.X+def.Size.Width)*sx, (def.Pos.Y+def.Size.Height)*sy)
+		gotRect := image.Rect(got.Pos.X, got.Pos.Y, got.Pos.X+got.Size.Width, got.Pos.Y+got.Size.Height)
+		if !ok || origRect.Size() != gotRect.Size() || !samePixels(origAtlas, repackedAtlas, origRect, gotRect) {
+			mismatched = append(mismatched, def.Name)
+		}
+	}
+
+	for _, def := range orig.Images {
+		check(def)
+	}
+	for _, g := range orig.Groups {
+		for _, def := range g.Images {
+			check(def)
+		}
+	}
+
+	return renamed, mismatched, nil
+}
+
+// atlasScale returns the integer scale of the atlas relative to RefSize,
+// the same way unpack does.
+func atlasScale(doc *imageset.Document, bounds image.Rectangle) (sx, sy int) {
+	sx, sy = 1, 1
+	if doc.RefSize.Width > 0 && bounds.Dx()%doc.RefSize.Width == 0 {
+		sx = max(bounds.Dx()/doc.RefSize.Width, 1)
+	}
+	if doc.RefSize.Height > 0 && bounds.Dy()%doc.RefSize.Height == 0 {
+		sy = max(bounds.Dy()/doc.RefSize.Height, 1)
+	}
+
+	return sx, sy
+}
+
+// samePixels compares two equally sized regions. A difference of one step
+// per channel is allowed: sprites pass through PNG, which stores
+// non-premultiplied color.
+func samePixels(a, b image.Image, ra, rb image.Rectangle) bool {
+	ra = ra.Add(a.Bounds().Min)
+	rb = rb.Add(b.Bounds().Min)
+	if !ra.In(a.Bounds()) || !rb.In(b.Bounds()) {
+		return false
+	}
+
+	for y := 0; y < ra.Dy(); y++ {
+		for x := 0; x < ra.Dx(); x++ {
+			r1, g1, b1, a1 := a.At(ra.Min.X+x, ra.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(rb.Min.X+x, rb.Min.Y+y).RGBA()
+			if channelDiff(r1, r2) > 1 || channelDiff(g1, g2) > 1 || channelDiff(b1, b2) > 1 || channelDiff(a1, a2) > 1 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// channelDiff returns the absolute 8-bit difference of two 16-bit channels.
+func channelDiff(a, b uint32) uint32 {
+	a, b = a>>8, b>>8
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
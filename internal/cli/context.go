@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// cancelGrace is how long runCancelable waits for fn after cancellation, so
+// callers do not remove work directories while fn still writes into them.
+const cancelGrace = 2 * time.Second
+
+// ErrInterrupted reports that a command was stopped by SIGINT/SIGTERM.
+var ErrInterrupted = errors.New("interrupted")
+
+// contextCommander is implemented by commands that support cancellation.
+type contextCommander interface {
+	ExecuteContext(ctx context.Context, args []string) error
+}
+
+// checkInterrupted returns ErrInterrupted once ctx is canceled.
+func checkInterrupted(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+
+	return nil
+}
+
+// runCancelable runs fn and returns ErrInterrupted once ctx is canceled and
+// fn returned, or after cancelGrace. Encoders do not observe ctx themselves,
+// so a slow fn keeps running in background until the process exits; callers
+// must discard anything fn writes.
+// A panic in fn is returned as an error for the crash handler.
+func runCancelable(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { done <- err }()
+		defer recoverPanic(&err)
+		err = fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		timer := time.NewTimer(cancelGrace)
+		defer timer.Stop()
+		select {
+		case <-done:
+		case <-timer.C:
+		}
+		return ErrInterrupted
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunCancelableWaitsForFn(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var finished atomic.Bool
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	err := runCancelable(ctx, func() error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("runCancelable = %v, want ErrInterrupted", err)
+	}
+	if !finished.Load() {
+		t.Fatal("runCancelable returned while fn was still running")
+	}
+}
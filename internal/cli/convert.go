@@ -1,40 +1,99 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
 
+	"github.com/woozymasta/imageset-packer/edds"
 	"github.com/woozymasta/imageset-packer/internal/imageio"
 )
 
 // CmdConvert converts a single image between supported formats.
 type CmdConvert struct {
 	Args struct {
-		Input  string `positional-arg-name:"input" description:"Input file: png,tga,tiff,bmp,dds,edds" required:"yes"`
-		Output string `positional-arg-name:"output" description:"Output file: png,tga,tiff,bmp,dds,edds" required:"yes"`
-	} `positional-args:"yes" required:"yes"`
+		Input  string `positional-arg-name:"input" description:"Input file: png,tga,tiff,bmp,dds,edds"`
+		Output string `positional-arg-name:"output" description:"Output file: png,tga,tiff,bmp,dds,edds"`
+	} `positional-args:"yes"`
 
-	AlphaKey    string `long:"alpha-key" description:"Color key as RRGGBB -> alpha=0" default:""`
-	Format      string `short:"F" long:"format" description:"Output format for DDS/EDDS" choice:"bgra8" choice:"dxt1" choice:"dxt5" default:"bgra8"`
+	Batch string `long:"batch" description:"CSV or YAML mapping of input to output paths with per-file options, converted on shared workers instead of input/output"`
+	Jobs  int    `short:"j" long:"jobs" description:"Concurrent conversions of --batch, 0=number of CPUs" default:"0"`
+
+	AlphaKey    string `long:"alpha-key" description:"Color key as RRGGBB -> alpha=0; auto=detect the border color" default:""`
+	KeyMode     string `long:"alpha-key-mode" description:"Color key handling: transparent=alpha 0, replace=paint --alpha-key-color, neighbors=paint nearby colors" choice:"transparent" choice:"replace" choice:"neighbors" default:"transparent"`
+	KeyColor    string `long:"alpha-key-color" description:"Replacement color as RRGGBB for --alpha-key-mode replace"`
+	Format      string `short:"F" long:"format" description:"Output format for DDS/EDDS (bc5 keeps red and green only, for normal maps)" choice:"bgra8" choice:"dxt1" choice:"dxt5" choice:"bc5" default:"bgra8"`
 	Quality     int    `short:"q" long:"quality" description:"DXT1/DXT5 quality level 1..10, 0=optimal" default:"0"`
 	Mipmaps     int    `short:"x" long:"mipmaps" description:"Mipmap levels for DDS/EDDS output, 0=full chain" default:"0"`
+	MipCap      int    `long:"mip-cap" description:"Maximum levels of a full mip chain (--mipmaps 0): 0=11 like the Enfusion tools, -1=no cap" default:"0"`
+	MinMipSize  int    `long:"min-mip-size" description:"Stop the mip chain before a level with a side below N (e.g. 4 for BCn blocks), 0=down to 1x1" default:"0"`
+	Compression string `long:"compression" description:"EDDS block compression: hc=smallest, balanced=hc for the base mip only, fast, none" choice:"hc" choice:"balanced" choice:"fast" choice:"none" default:"hc"`
 	AlphaKeyOff bool   `long:"alpha-key-off" description:"Disable color key processing"`
+	Verify      bool   `long:"verify-output" description:"Re-read the written EDDS and compare its base mip with the input"`
+	Passthrough bool   `long:"passthrough" description:"Copy validated input bytes when input and output formats match and no option changes the image; others are converted"`
 }
 
 // Execute runs the convert command.
 func (c *CmdConvert) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the convert command.
+func (c *CmdConvert) ExecuteContext(ctx context.Context, args []string) error {
+	if c.Batch != "" {
+		if c.Args.Input != "" {
+			return fmt.Errorf("--batch takes no input and output arguments")
+		}
+		return runConvertBatch(ctx, c)
+	}
+	if c.Args.Input == "" || c.Args.Output == "" {
+		return fmt.Errorf("input and output files are required (or use --batch)")
+	}
+	if err := checkWritable(ctx, c.Args.Output); err != nil {
+		return err
+	}
+
+	return convertFile(c)
+}
+
+// convertFile converts c.Args.Input to c.Args.Output with the options of c,
+// or copies it with --passthrough when nothing has to change.
+func convertFile(c *CmdConvert) error {
+	if c.Passthrough {
+		reason := passthroughReason(c)
+		if reason == "" {
+			return copyVerified(c.Args.Input, c.Args.Output)
+		}
+		fmt.Printf("Passthrough of %s not possible (%s); converting\n", c.Args.Input, reason)
+	}
+
 	img, err := imageio.Read(c.Args.Input)
 	if err != nil {
 		return err
 	}
 
 	if !c.AlphaKeyOff && c.AlphaKey != "" {
-		rgb, err := imageio.ParseHexRGB(c.AlphaKey)
+		key, err := parseColorKey(c.AlphaKey)
 		if err != nil {
-			return fmt.Errorf("invalid --alpha-key: %w", err)
+			return err
+		}
+		keyOpts, err := parseColorKeyOptions(c.KeyMode, c.KeyColor)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			detected, ok := imageio.DetectColorKey(img)
+			if ok {
+				fmt.Printf("Auto alpha key of %s: %s\n", c.Args.Input, detected)
+				key = &detected
+			} else {
+				fmt.Printf("Auto alpha key of %s: no flat border color found, key not applied\n", c.Args.Input)
+			}
+		}
+		if key != nil {
+			img = imageio.ApplyColorKeyWithOptions(img, *key, keyOpts)
 		}
-		img = imageio.ApplyColorKey(img, rgb)
 	}
 
 	// Optional sanity: output ext known
@@ -46,6 +105,12 @@ func (c *CmdConvert) Execute(args []string) error {
 	if c.Mipmaps < 0 {
 		return fmt.Errorf("mipmaps must be >= 0")
 	}
+	if c.MinMipSize < 0 {
+		return fmt.Errorf("min-mip-size must be >= 0")
+	}
+	if c.MipCap < edds.NoMipCap {
+		return fmt.Errorf("mip-cap must be >= %d", edds.NoMipCap)
+	}
 	if err := imageio.ValidateQualityLevel(c.Quality); err != nil {
 		return fmt.Errorf("invalid --quality: %w", err)
 	}
@@ -54,17 +119,29 @@ func (c *CmdConvert) Execute(args []string) error {
 	if err != nil {
 		return fmt.Errorf("invalid --format: %w", err)
 	}
+	compression, err := imageio.ParseCompression(c.Compression)
+	if err != nil {
+		return fmt.Errorf("invalid --compression: %w", err)
+	}
 
 	if ext != "dds" && ext != "edds" {
 		return imageio.Write(c.Args.Output, img)
 	}
-	if ext == "dds" && c.Mipmaps != 0 {
-		return fmt.Errorf("--mipmaps is supported only for edds output")
-	}
 
-	return imageio.WriteWithOptions(c.Args.Output, img, &imageio.EncodeSettings{
-		Format:  outputFormat,
-		Quality: c.Quality,
-		Mipmaps: c.Mipmaps,
+	err = imageio.WriteWithOptions(c.Args.Output, img, &imageio.EncodeSettings{
+		Format:      outputFormat,
+		Quality:     c.Quality,
+		Mipmaps:     c.Mipmaps,
+		MinMipSize:  c.MinMipSize,
+		MipCap:      c.MipCap,
+		Compression: compression,
 	})
+	if err != nil || !c.Verify || ext != "edds" {
+		return err
+	}
+	if err := imageio.VerifyEDDS(c.Args.Output, img, outputFormat); err != nil {
+		return fmt.Errorf("verify output: %w", err)
+	}
+
+	return nil
 }
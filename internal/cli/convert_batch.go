@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// convertJob is one entry of a --batch mapping. Nil options keep the value
+// given on the command line.
+type convertJob struct {
+	Input         string  `yaml:"input"`
+	Output        string  `yaml:"output"`
+	AlphaKey      *string `yaml:"alpha_key"`
+	AlphaKeyMode  *string `yaml:"alpha_key_mode"`
+	AlphaKeyColor *string `yaml:"alpha_key_color"`
+	Format        *string `yaml:"format"`
+	Compression   *string `yaml:"compression"`
+	Quality       *int    `yaml:"quality"`
+	Mipmaps       *int    `yaml:"mipmaps"`
+	MipCap        *int    `yaml:"mip_cap"`
+	MinMipSize    *int    `yaml:"min_mip_size"`
+	AlphaKeyOff   *bool   `yaml:"alpha_key_off"`
+	Verify        *bool   `yaml:"verify_output"`
+	Passthrough   *bool   `yaml:"passthrough"`
+}
+
+// apply returns a copy of base with the paths and options of the job.
+func (j convertJob) apply(base *CmdConvert) *CmdConvert {
+	c := *base
+	c.Batch = ""
+	c.Args.Input, c.Args.Output = j.Input, j.Output
+
+	setOption(&c.AlphaKey, j.AlphaKey)
+	setOption(&c.KeyMode, j.AlphaKeyMode)
+	setOption(&c.KeyColor, j.AlphaKeyColor)
+	setOption(&c.Format, j.Format)
+	setOption(&c.Compression, j.Compression)
+	setOption(&c.Quality, j.Quality)
+	setOption(&c.Mipmaps, j.Mipmaps)
+	setOption(&c.MipCap, j.MipCap)
+	setOption(&c.MinMipSize, j.MinMipSize)
+	setOption(&c.AlphaKeyOff, j.AlphaKeyOff)
+	setOption(&c.Verify, j.Verify)
+	setOption(&c.Passthrough, j.Passthrough)
+
+	return &c
+}
+
+// setOption overwrites dst with v unless v is nil.
+func setOption[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// runConvertBatch converts every file of the --batch mapping on up to
+// --jobs workers. Paths are relative to the mapping file. The first failed
+// conversion stops the batch; files converted so far are kept.
+func runConvertBatch(ctx context.Context, opts *CmdConvert) error {
+	if opts.Jobs < 0 {
+		return fmt.Errorf("jobs must be >= 0")
+	}
+
+	jobs, err := readConvertJobs(opts.Batch)
+	if err != nil {
+		return err
+	}
+
+	outputs := make([]string, len(jobs))
+	for i, job := range jobs {
+		outputs[i] = job.Output
+	}
+	if err := checkWritable(ctx, outputs...); err != nil {
+		return err
+	}
+
+	workers := opts.Jobs
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var done atomic.Int64
+	err = parallelFor(ctx, len(jobs), min(workers, len(jobs)), func(i int) error {
+		job := jobs[i]
+		if err := os.MkdirAll(filepath.Dir(job.Output), 0750); err != nil {
+			return fmt.Errorf("convert %s: %w", job.Input, err)
+		}
+		if err := convertFile(job.apply(opts)); err != nil {
+			return fmt.Errorf("convert %s: %w", job.Input, err)
+		}
+		done.Add(1)
+
+		return nil
+	})
+	fmt.Printf("Converted %d of %d file(s) from %s\n", done.Load(), len(jobs), opts.Batch)
+
+	return err
+}
+
+// readConvertJobs reads a --batch mapping: a .csv file with a header row
+// naming the input, output and option columns, or a YAML list of jobs.
+// Relative paths are resolved against the mapping directory.
+func readConvertJobs(path string) ([]convertJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read batch mapping: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		if data, err = convertCSVToYAML(data); err != nil {
+			return nil, fmt.Errorf("parse %q: %w", path, err)
+		}
+	}
+
+	var jobs []convertJob
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&jobs); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no conversions found in %q", path)
+	}
+
+	baseDir := filepath.Dir(path)
+	seen := make(map[string]int, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		if strings.TrimSpace(job.Input) == "" || strings.TrimSpace(job.Output) == "" {
+			return nil, fmt.Errorf("%s: entry %d: input and output are required", path, i+1)
+		}
+		for _, p := range []*string{&job.Input, &job.Output} {
+			normalized, err := normalizeConfigPath(*p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %w", path, i+1, err)
+			}
+			*p = filepath.Clean(resolveRelativePath(baseDir, normalized))
+		}
+		if prev, ok := seen[job.Output]; ok {
+			return nil, fmt.Errorf("%s: entries %d and %d write the same output %q", path, prev, i+1, job.Output)
+		}
+		seen[job.Output] = i + 1
+	}
+
+	return jobs, nil
+}
+
+// convertCSVToYAML turns CSV rows into a YAML list of jobs keyed by the
+// header row, so both mapping formats decode and validate alike. Empty
+// cells are left out and keep the command line value.
+func convertCSVToYAML(data []byte) ([]byte, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range rows[1:] {
+		entry := &yaml.Node{Kind: yaml.MappingNode}
+		for i, cell := range row {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				continue
+			}
+			entry.Content = append(entry.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: header[i]},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: cell},
+			)
+		}
+		list.Content = append(list.Content, entry)
+	}
+
+	return yaml.Marshal(list)
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+func TestReadConvertJobs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "map.csv")
+	writeTestFile(t, csvPath, "input, output, format, quality, alpha_key\n"+
+		"art/a.png, out/a.edds, dxt5, 8,\n"+
+		"# comments are skipped\n"+
+		"art\\b.bmp, out/b.png, , , 000000\n")
+	yamlPath := filepath.Join(dir, "map.yaml")
+	writeTestFile(t, yamlPath, `
+- input: art/a.png
+  output: out/a.edds
+  format: dxt5
+  quality: 8
+- input: art\b.bmp
+  output: out/b.png
+  alpha_key: "000000"
+`)
+
+	for _, path := range []string{csvPath, yamlPath} {
+		jobs, err := readConvertJobs(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(jobs) != 2 {
+			t.Fatalf("%s: got %d jobs", path, len(jobs))
+		}
+		if jobs[0].Input != filepath.Join(dir, "art", "a.png") || jobs[1].Output != filepath.Join(dir, "out", "b.png") {
+			t.Fatalf("%s: paths %q -> %q, %q -> %q", path, jobs[0].Input, jobs[0].Output, jobs[1].Input, jobs[1].Output)
+		}
+		if jobs[1].Input != filepath.Join(dir, "art", "b.bmp") {
+			t.Fatalf("%s: separators not normalized: %q", path, jobs[1].Input)
+		}
+
+		base := &CmdConvert{Format: "bgra8", Quality: 0, AlphaKey: "ff00ff", MipCap: 11}
+		first, second := jobs[0].apply(base), jobs[1].apply(base)
+		if first.Format != "dxt5" || first.Quality != 8 || first.AlphaKey != "ff00ff" || first.MipCap != 11 {
+			t.Fatalf("%s: first job options %+v", path, first)
+		}
+		if second.Format != "bgra8" || second.AlphaKey != "000000" {
+			t.Fatalf("%s: second job options %+v", path, second)
+		}
+		if base.Format != "bgra8" || base.Args.Input != "" {
+			t.Fatalf("%s: apply modified the base options", path)
+		}
+	}
+}
+
+func TestReadConvertJobsRejects(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.csv":   "input,output,qualty\na.png,a.edds,8\n",
+		"missing.yaml":  "- input: a.png\n",
+		"same.yaml":     "- {input: a.png, output: out.png}\n- {input: b.png, output: ./out.png}\n",
+		"empty.csv":     "input,output\n",
+		"unknown.yaml":  "- {input: a.png, output: a.edds, mips: 1}\n",
+		"not-list.yaml": "input: a.png\n",
+	} {
+		path := filepath.Join(dir, name)
+		writeTestFile(t, path, content)
+		if _, err := readConvertJobs(path); err == nil {
+			t.Fatalf("%s: readConvertJobs succeeded", name)
+		}
+	}
+}
+
+func TestRunConvertBatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range 8 {
+		img.SetNRGBA(i, i, color.NRGBA{R: 200, A: 255})
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "art"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		if err := imageio.Write(filepath.Join(dir, "art", name), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mapping := filepath.Join(dir, "map.csv")
+	writeTestFile(t, mapping, "input,output,mipmaps\nart/a.png,out/a.edds,1\nart/b.png,out/nested/b.tga,\nart/c.png,out/c.edds,\n")
+
+	opts := &CmdConvert{Batch: mapping, Format: "bgra8", Compression: "fast", MipCap: 11, Jobs: 2}
+	if err := runConvertBatch(context.Background(), opts); err != nil {
+		t.Fatalf("runConvertBatch: %v", err)
+	}
+	for _, name := range []string{"a.edds", "nested/b.tga", "c.edds"} {
+		got, err := imageio.Read(filepath.Join(dir, "out", filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if got.Bounds().Dx() != 8 || got.Bounds().Dy() != 8 {
+			t.Fatalf("%s is %v", name, got.Bounds())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/creasty/defaults"
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// passthroughReason returns why c has to decode and re-encode its input
+// instead of copying it with --passthrough, or "" when bytes can be copied:
+// input and output share a format and no option changes pixels or encoding.
+func passthroughReason(c *CmdConvert) string {
+	in := strings.ToLower(strings.TrimPrefix(filepath.Ext(c.Args.Input), "."))
+	out := strings.ToLower(strings.TrimPrefix(filepath.Ext(c.Args.Output), "."))
+	if in != out {
+		return fmt.Sprintf("input is %s, output is %s", in, out)
+	}
+	if !c.AlphaKeyOff && c.AlphaKey != "" {
+		return "--alpha-key is set"
+	}
+	if out != "dds" && out != "edds" {
+		return ""
+	}
+
+	var d CmdConvert
+	if err := defaults.Set(&d); err != nil {
+		return err.Error()
+	}
+	switch {
+	case c.Format != d.Format:
+		return "--format is set"
+	case c.Quality != d.Quality:
+		return "--quality is set"
+	case c.Mipmaps != d.Mipmaps || c.MipCap != d.MipCap || c.MinMipSize != d.MinMipSize:
+		return "mip options are set"
+	case c.Compression != d.Compression:
+		return "--compression is set"
+	}
+
+	return ""
+}
+
+// copyVerified copies src to dst as it is. src must decode completely as
+// an image of its format, so truncated or corrupt files are refused. The
+// copy is re-read and its checksum compared with the checksum of the
+// streamed bytes, and removed on a mismatch.
+func copyVerified(src, dst string) error {
+	if _, err := imageio.Read(src); err != nil {
+		return fmt.Errorf("validate %q: %w", src, err)
+	}
+	if srcInfo, err := os.Stat(src); err != nil {
+		return err
+	} else if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(srcInfo, dstInfo) {
+		return fmt.Errorf("input and output are the same file %q", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	h := xxhash.New()
+	_, err = io.Copy(io.MultiWriter(out, h), in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		var sum uint64
+		if sum, err = hashFileXX(dst); err == nil && sum != h.Sum64() {
+			err = fmt.Errorf("checksum mismatch: wrote %016x, read back %016x", h.Sum64(), sum)
+		}
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("copy %q: %w", src, err)
+	}
+
+	return nil
+}
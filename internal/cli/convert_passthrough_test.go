@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creasty/defaults"
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+func TestPassthroughReason(t *testing.T) {
+	t.Parallel()
+
+	base := CmdConvert{}
+	if err := defaults.Set(&base); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		edit   func(c *CmdConvert)
+		name   string
+		input  string
+		output string
+		copy   bool
+	}{
+		{name: "same edds", input: "a.edds", output: "b.EDDS", copy: true},
+		{name: "same png", input: "a.png", output: "b.png", copy: true},
+		{name: "png ignores encoder options", input: "a.png", output: "b.png", copy: true, edit: func(c *CmdConvert) { c.Format = "dxt5" }},
+		{name: "other format", input: "a.png", output: "b.tga"},
+		{name: "alpha key", input: "a.tga", output: "b.tga", edit: func(c *CmdConvert) { c.AlphaKey = "ff00ff" }},
+		{name: "alpha key off", input: "a.tga", output: "b.tga", copy: true, edit: func(c *CmdConvert) { c.AlphaKey, c.AlphaKeyOff = "ff00ff", true }},
+		{name: "format", input: "a.edds", output: "b.edds", edit: func(c *CmdConvert) { c.Format = "dxt1" }},
+		{name: "mipmaps", input: "a.edds", output: "b.edds", edit: func(c *CmdConvert) { c.Mipmaps = 1 }},
+		{name: "compression", input: "a.edds", output: "b.edds", edit: func(c *CmdConvert) { c.Compression = "fast" }},
+	}
+	for _, tt := range tests {
+		c := base
+		c.Args.Input, c.Args.Output = tt.input, tt.output
+		if tt.edit != nil {
+			tt.edit(&c)
+		}
+		if reason := passthroughReason(&c); (reason == "") != tt.copy {
+			t.Fatalf("%s: passthroughReason = %q, want copy %v", tt.name, reason, tt.copy)
+		}
+	}
+}
+
+func TestCopyVerified(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.png")
+	if err := imageio.Write(src, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "out.png")
+	if err := copyVerified(src, dst); err != nil {
+		t.Fatalf("copyVerified: %v", err)
+	}
+	want, _ := os.ReadFile(src)
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, want) {
+		t.Fatal("copy differs from the input")
+	}
+	if err := copyVerified(src, src); err == nil {
+		t.Fatal("copyVerified onto itself succeeded")
+	}
+
+	bad := filepath.Join(dir, "bad.png")
+	writeTestFile(t, bad, "not a png")
+	if err := copyVerified(bad, filepath.Join(dir, "bad-out.png")); err == nil {
+		t.Fatal("copyVerified of an invalid image succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad-out.png")); !os.IsNotExist(err) {
+		t.Fatalf("invalid image was copied: %v", err)
+	}
+
+	// A truncated file keeps a valid header but lacks pixel data.
+	truncated := filepath.Join(dir, "truncated.png")
+	writeTestFile(t, truncated, string(want[:len(want)/2]))
+	if err := copyVerified(truncated, filepath.Join(dir, "truncated-out.png")); err == nil {
+		t.Fatal("copyVerified of a truncated image succeeded")
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/woozymasta/imageset-packer/internal/vars"
+	"gopkg.in/yaml.v3"
+)
+
+// maxCrashListing limits files listed per input path in a crash bundle.
+const maxCrashListing = 2000
+
+// panicError carries a recovered panic and the stack it was raised on.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+// Error implements error.
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// recoverPanic turns a panic into a *panicError stored in *err. It must be
+// deferred directly.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &panicError{value: r, stack: debug.Stack()}
+	}
+}
+
+// warningRecorder is implemented by commands that keep a warning log.
+type warningRecorder interface {
+	recordedWarnings() []warningRecord
+}
+
+// executeRecovered runs cmd and, if it panics, writes a diagnostic bundle
+// and returns an error naming it instead of crashing.
+func executeRecovered(ctx context.Context, cmd flags.Commander, args []string) (err error) {
+	defer func() {
+		var pe *panicError
+		if r := recover(); r != nil {
+			pe = &panicError{value: r, stack: debug.Stack()}
+		} else {
+			errors.As(err, &pe)
+		}
+		if pe == nil {
+			return
+		}
+
+		dir, bundleErr := writeCrashBundle(cmd, pe)
+		if bundleErr != nil {
+			err = fmt.Errorf("internal error: %v (writing diagnostics failed: %v)\n%s", pe.value, bundleErr, pe.stack)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Diagnostic bundle written to %s\nPlease attach it to a bug report at %s/issues\n", dir, vars.URL)
+		err = fmt.Errorf("internal error: %v", pe.value)
+	}()
+
+	if c, ok := cmd.(contextCommander); ok {
+		return c.ExecuteContext(ctx, args)
+	}
+
+	return cmd.Execute(args)
+}
+
+// writeCrashBundle writes panic.txt (panic, stack, build and command line),
+// settings.yaml (parsed command options), inputs.txt (files named on the
+// command line) and warnings.json (warnings so far) to a new temp directory.
+func writeCrashBundle(cmd flags.Commander, pe *panicError) (string, error) {
+	dir, err := os.MkdirTemp("", "imageset-packer-crash-")
+	if err != nil {
+		return "", err
+	}
+
+	info := vars.Info()
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %v\n\n", pe.value)
+	fmt.Fprintf(&b, "version: %s\ncommit:  %s\nbuilt:   %s\n", info.Version, info.Commit, info.BuildTime)
+	fmt.Fprintf(&b, "go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "args:    %q\n\n", os.Args)
+	b.Write(pe.stack)
+	if err := os.WriteFile(filepath.Join(dir, "panic.txt"), []byte(b.String()), 0600); err != nil {
+		return dir, err
+	}
+
+	if settings, err := yaml.Marshal(cmd); err == nil {
+		if err := os.WriteFile(filepath.Join(dir, "settings.yaml"), settings, 0600); err != nil {
+			return dir, err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "inputs.txt"), []byte(listCrashInputs(os.Args[1:])), 0600); err != nil {
+		return dir, err
+	}
+
+	if r, ok := cmd.(warningRecorder); ok {
+		records := r.recordedWarnings()
+		if records == nil {
+			records = []warningRecord{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, "warnings.json"), append(data, '\n'), 0600)
+		}
+		if err != nil {
+			return dir, err
+		}
+	}
+
+	return dir, nil
+}
+
+// listCrashInputs lists every existing path among args with file sizes;
+// directories are walked up to maxCrashListing files.
+func listCrashInputs(args []string) string {
+	var b strings.Builder
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			fmt.Fprintf(&b, "%s\t%d\n", arg, info.Size())
+			continue
+		}
+
+		listed := 0
+		_ = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if listed == maxCrashListing {
+				fmt.Fprintf(&b, "%s\t... more files not listed\n", arg)
+				return filepath.SkipAll
+			}
+			listed++
+
+			if fi, err := d.Info(); err == nil {
+				fmt.Fprintf(&b, "%s\t%d\n", path, fi.Size())
+			}
+			return nil
+		})
+	}
+
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// panicCommand panics in Execute, in a runCancelable goroutine or in a
+// parallelFor worker.
+type panicCommand struct {
+	Input      string `yaml:"input"`
+	inWorker   bool
+	inParallel bool
+}
+
+func (c *panicCommand) Execute([]string) error {
+	switch {
+	case c.inWorker:
+		return runCancelable(context.Background(), func() error { panic("worker boom") })
+	case c.inParallel:
+		return parallelFor(context.Background(), 8, 4, func(i int) error {
+			if i == 5 {
+				panic("parallel boom")
+			}
+			return nil
+		})
+	}
+	panic("boom")
+}
+
+func (c *panicCommand) recordedWarnings() []warningRecord {
+	return nil
+}
+
+func TestExecuteRecoveredWritesBundle(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	for _, cmd := range []*panicCommand{{Input: "icons"}, {Input: "icons", inWorker: true}, {Input: "icons", inParallel: true}} {
+		err := executeRecovered(context.Background(), cmd, nil)
+		if err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("executeRecovered = %v, want internal error", err)
+		}
+	}
+
+	bundles, err := filepath.Glob(filepath.Join(tmp, "imageset-packer-crash-*"))
+	if err != nil || len(bundles) != 3 {
+		t.Fatalf("got bundles %q (%v), want 3", bundles, err)
+	}
+	for _, name := range []string{"panic.txt", "settings.yaml", "inputs.txt", "warnings.json"} {
+		data, err := os.ReadFile(filepath.Join(bundles[0], name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if name == "panic.txt" && !strings.Contains(string(data), "goroutine") {
+			t.Fatalf("panic.txt has no stack trace:\n%s", data)
+		}
+		if name == "settings.yaml" && !strings.Contains(string(data), "input: icons") {
+			t.Fatalf("settings.yaml = %q", data)
+		}
+		if name == "warnings.json" && strings.TrimSpace(string(data)) != "[]" {
+			t.Fatalf("warnings.json = %q, want []", data)
+		}
+	}
+
+	if err := executeRecovered(context.Background(), &CmdVersion{}, nil); err != nil {
+		t.Fatalf("executeRecovered(version) = %v", err)
+	}
+}
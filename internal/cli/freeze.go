@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset"
+)
+
+// frozenLayout holds sprites kept at their coordinates from a previous
+// imageset and the size of that atlas.
+type frozenLayout struct {
+	placements []atlasforge.Placement
+	width      int
+	height     int
+}
+
+// checkFreezeRotation refuses --freeze and --freeze-from with rotation
+// enabled for the project or a group: the imageset does not record which
+// sprites were rotated, so frozen sprites could not be placed as before.
+func checkFreezeRotation(flags *PackPackingFlags, overrides map[string]groupOverride) error {
+	if len(flags.Freeze) == 0 && flags.FreezeFrom == "" {
+		return nil
+	}
+	if flags.AllowRotate {
+		return fmt.Errorf("--freeze and --freeze-from cannot be used with --rotate")
+	}
+	var rotated []string
+	for group, o := range overrides {
+		if o.rotate != nil && *o.rotate {
+			rotated = append(rotated, group)
+		}
+	}
+	if len(rotated) > 0 {
+		return fmt.Errorf("--freeze and --freeze-from cannot be used with rotated groups: %s", listNames(rotated))
+	}
+
+	return nil
+}
+
+// resolveFrozen returns placements for --freeze and --freeze-from. The
+// previous layout is read from --freeze-from or, for --freeze alone, from
+// the current output imageset. --freeze-from without names freezes every
+// input sprite found there. Names are compared after imageset name
+// normalization, so "icon-b" matches "icon_b". Names of the previous
+// imageset carry the sprite namespace, if any, which is stripped first.
+func resolveFrozen(flags *PackPackingFlags, imagesetPath string, files []imageFile, namespace string) (frozenLayout, error) {
+	if len(flags.Freeze) == 0 && flags.FreezeFrom == "" {
+		return frozenLayout{}, nil
+	}
+
+	source := flags.FreezeFrom
+	if source == "" {
+		source = imagesetPath
+	}
+	doc, err := imageset.ParseFile(source)
+	if err != nil {
+		return frozenLayout{}, fmt.Errorf("read freeze source: %w", err)
+	}
+
+	prefix := ""
+	if namespace != "" {
+		prefix = imageset.NormalizeName(namespace, false) + "_"
+	}
+	previous := make(map[string]imageset.Image)
+	add := func(def imageset.Image) {
+		previous[strings.TrimPrefix(imageset.NormalizeName(def.Name, false), prefix)] = def
+	}
+	for _, def := range doc.Images {
+		add(def)
+	}
+	for _, g := range doc.Groups {
+		for _, def := range g.Images {
+			add(def)
+		}
+	}
+
+	inputs := make(map[string]*imageFile, len(files))
+	for i := range files {
+		inputs[imageset.NormalizeName(files[i].name, false)] = &files[i]
+	}
+
+	names := make([]string, 0, len(flags.Freeze))
+	for _, name := range splitList(flags.Freeze) {
+		key := imageset.NormalizeName(name, false)
+		if inputs[key] == nil {
+			return frozenLayout{}, fmt.Errorf("frozen sprite %q is not an input image", name)
+		}
+		if _, ok := previous[key]; !ok {
+			return frozenLayout{}, fmt.Errorf("frozen sprite %q not found in %s", name, source)
+		}
+		names = append(names, key)
+	}
+	if len(names) == 0 {
+		for _, f := range files {
+			if key := imageset.NormalizeName(f.name, false); previous[key].Name != "" {
+				names = append(names, key)
+			}
+		}
+	}
+
+	layout := frozenLayout{width: doc.RefSize.Width, height: doc.RefSize.Height}
+	seen := make(map[string]bool, len(names))
+	for _, key := range names {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		f, def := inputs[key], previous[key]
+		if def.Size.Width != f.width || def.Size.Height != f.height {
+			return frozenLayout{}, fmt.Errorf(
+				"frozen sprite %q changed size: %dx%d in %s, %dx%d now",
+				f.name, def.Size.Width, def.Size.Height, source, f.width, f.height,
+			)
+		}
+		layout.placements = append(layout.placements, atlasforge.Placement{
+			ID:     f.name,
+			X:      def.Pos.X,
+			Y:      def.Pos.Y,
+			Width:  f.width,
+			Height: f.height,
+		})
+	}
+
+	return layout, nil
+}
+
+// warnFrozenGrowth warns when the atlas outgrew the previous one. Frozen
+// pixel positions still hold, but normalized UVs computed against the old
+// size do not.
+func warnFrozenGrowth(opts *CmdPack, frozen frozenLayout, layout *atlasforge.Layout) {
+	if len(frozen.placements) == 0 {
+		return
+	}
+	if layout.Width != frozen.width || layout.Height != frozen.height {
+		opts.warn(warnFrozenResized, "", "atlas resized from %dx%d to %dx%d; %d frozen sprites kept their pixel positions",
+			frozen.width, frozen.height, layout.Width, layout.Height, len(frozen.placements))
+	}
+}
+
+// splitList splits repeatable, comma separated flag values and drops
+// empty entries.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/woozymasta/imageset"
+)
+
+func TestResolveFrozen(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "old.imageset")
+	doc := &imageset.Document{
+		Name:    "old",
+		RefSize: imageset.Size{Width: 512, Height: 256},
+		Images: []imageset.Image{
+			{Name: "icon_a", Pos: imageset.Point{X: 4, Y: 8}, Size: imageset.Size{Width: 16, Height: 16}},
+		},
+		Groups: []imageset.Group{{Name: "g", Images: []imageset.Image{
+			{Name: "icon_b", Pos: imageset.Point{X: 40, Y: 8}, Size: imageset.Size{Width: 32, Height: 8}},
+		}}},
+	}
+	if err := imageset.WriteFile(path, doc, nil); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	files := []imageFile{
+		{name: "icon_a", width: 16, height: 16},
+		{name: "icon_b", groupName: "g", width: 32, height: 8},
+		{name: "icon_new", width: 10, height: 10},
+	}
+
+	all, err := resolveFrozen(&PackPackingFlags{FreezeFrom: path}, "", files, "")
+	if err != nil {
+		t.Fatalf("resolveFrozen: %v", err)
+	}
+	if len(all.placements) != 2 || all.width != 512 || all.height != 256 {
+		t.Fatalf("got %+v, want 2 placements in 512x256", all)
+	}
+
+	one, err := resolveFrozen(&PackPackingFlags{Freeze: []string{"icon-b,"}}, path, files, "")
+	if err != nil {
+		t.Fatalf("resolveFrozen: %v", err)
+	}
+	if len(one.placements) != 1 || one.placements[0].ID != "icon_b" || one.placements[0].X != 40 {
+		t.Fatalf("got %+v, want icon_b at 40,8", one.placements)
+	}
+
+	for _, names := range []string{"icon_new", "missing"} {
+		if _, err := resolveFrozen(&PackPackingFlags{Freeze: []string{names}}, path, files, ""); err == nil {
+			t.Fatalf("freeze %s: expected error", names)
+		}
+	}
+	files[0].width = 20
+	if _, err := resolveFrozen(&PackPackingFlags{FreezeFrom: path}, "", files, ""); err == nil {
+		t.Fatal("expected error for resized sprite")
+	}
+}
+
+func TestCheckFreezeRotation(t *testing.T) {
+	t.Parallel()
+
+	overrides, err := parseGroupOverrides([]string{"icons:rotate=true", "flags:rotate=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		flags     PackPackingFlags
+		overrides map[string]groupOverride
+		wantErr   bool
+	}{
+		{flags: PackPackingFlags{AllowRotate: true}, overrides: overrides},
+		{flags: PackPackingFlags{Freeze: []string{"a"}}},
+		{flags: PackPackingFlags{Freeze: []string{"a"}, AllowRotate: true}, wantErr: true},
+		{flags: PackPackingFlags{FreezeFrom: "old.imageset"}, overrides: overrides, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		if err := checkFreezeRotation(&tt.flags, tt.overrides); (err != nil) != tt.wantErr {
+			t.Fatalf("case %d: checkFreezeRotation = %v, want error %v", i, err, tt.wantErr)
+		}
+	}
+}
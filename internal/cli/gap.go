@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/edds"
+)
+
+// recommendGap checks a gap against DXT block and mip sampling. It returns
+// the smallest gap >= gap that avoids color bleeding between sprites and
+// a note per problem found; gap is returned unchanged for other formats.
+func recommendGap(format string, gap, blockAlign, mipmaps int) (int, []string) {
+	format = strings.ToLower(format)
+	if format != "dxt1" && format != "dxt5" {
+		return gap, nil
+	}
+
+	want := gap
+	var notes []string
+	// Sprites are 2*gap apart, so a gap of 2 keeps every 4x4 block to one
+	// sprite even when slots are not block aligned.
+	if (blockAlign < 4 || blockAlign%4 != 0) && want < 2 {
+		notes = append(notes, fmt.Sprintf("gap %d lets a 4x4 %s block hold pixels of two sprites", gap, format))
+		want = 2
+	}
+	// Each mip level halves the gap and doubles the block footprint.
+	if mipmaps != 1 && want < 2 {
+		notes = append(notes, fmt.Sprintf("gap %d lets blocks of smaller mips mix neighboring sprites", gap))
+		want = 2
+	}
+	if mipmaps != 1 && want%2 != 0 {
+		notes = append(notes, fmt.Sprintf("odd gap %d puts sprite edges off the 2x2 mip grid, so mips blend neighbors", gap))
+		want++
+	}
+
+	return want, notes
+}
+
+// groupGap is the recommendGap result for the gap override of one group.
+type groupGap struct {
+	group string
+	gap   int
+	want  int
+	notes []string
+}
+
+// recommendGroupGaps runs recommendGap on the gap overrides of the groups
+// files belong to and returns the groups that need a larger gap, sorted by
+// name. Overrides of groups without files are left to resolveSpriteOptions.
+func recommendGroupGaps(files []imageFile, overrides map[string]groupOverride, format string, blockAlign, mipmaps int) []groupGap {
+	checked := make(map[string]bool)
+	var gaps []groupGap
+	for _, f := range files {
+		group := imageset.NormalizeName(f.groupName, false)
+		o, ok := overrides[group]
+		if !ok || f.groupName == "" || o.gap == nil || checked[group] {
+			continue
+		}
+		checked[group] = true
+
+		if want, notes := recommendGap(format, *o.gap, blockAlign, mipmaps); len(notes) > 0 {
+			gaps = append(gaps, groupGap{group: group, gap: *o.gap, want: want, notes: notes})
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].group < gaps[j].group })
+
+	return gaps
+}
+
+// mipSafeGap returns the gap that keeps sprites apart down to mip level
+// level, clamped to the last level written with mipmaps and mipCap (as
+// edds.WriteOptions.MipCap), and that level. At level n a texel covers
+// 2^n base pixels, so sprites 2*gap apart keep two clean texels between
+// them with a gap of 2^n; DXT blocks span 4 texels, so dxt1/dxt5 need
+// 2^(n+1).
+func mipSafeGap(format string, level, mipmaps, mipCap int) (gap, safeLevel int) {
+	last := level
+	if mipmaps > 0 {
+		last = mipmaps - 1
+	} else if mipCap == 0 {
+		last = edds.DefaultMipCap - 1
+	} else if mipCap > 0 {
+		last = mipCap - 1
+	}
+	level = min(level, last)
+	if level <= 0 {
+		return 0, 0
+	}
+
+	gap = 1 << level
+	if f := strings.ToLower(format); f == "dxt1" || f == "dxt5" {
+		gap <<= 1
+	}
+
+	return gap, level
+}
@@ -0,0 +1,88 @@
+package cli
+
+import "testing"
+
+func TestRecommendGap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format     string
+		gap        int
+		blockAlign int
+		mipmaps    int
+		want       int
+		notes      int
+	}{
+		{format: "bgra8", gap: 0, mipmaps: 0, want: 0},
+		{format: "dxt5", gap: 0, blockAlign: 4, mipmaps: 1, want: 0},
+		{format: "dxt5", gap: 0, blockAlign: 0, mipmaps: 1, want: 2, notes: 1},
+		{format: "dxt1", gap: 1, blockAlign: 0, mipmaps: 0, want: 2, notes: 1},
+		{format: "dxt5", gap: 0, blockAlign: 4, mipmaps: 0, want: 2, notes: 1},
+		{format: "DXT5", gap: 3, blockAlign: 4, mipmaps: 0, want: 4, notes: 1},
+		{format: "dxt5", gap: 3, blockAlign: 4, mipmaps: 1, want: 3},
+		{format: "dxt1", gap: 2, blockAlign: 0, mipmaps: 0, want: 2},
+	}
+
+	for _, tt := range tests {
+		got, notes := recommendGap(tt.format, tt.gap, tt.blockAlign, tt.mipmaps)
+		if got != tt.want || len(notes) != tt.notes {
+			t.Fatalf("recommendGap(%s, gap %d, align %d, mips %d) = %d %q, want %d with %d notes",
+				tt.format, tt.gap, tt.blockAlign, tt.mipmaps, got, notes, tt.want, tt.notes)
+		}
+	}
+}
+
+func TestRecommendGroupGaps(t *testing.T) {
+	t.Parallel()
+
+	overrides, err := parseGroupOverrides([]string{"icons:gap=0", "flags:gap=4", "empty:gap=0", "hud:rotate=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []imageFile{
+		{name: "a", groupName: "icons"},
+		{name: "b", groupName: "icons"},
+		{name: "c", groupName: "flags"},
+		{name: "d", groupName: "hud"},
+		{name: "e"},
+	}
+
+	gaps := recommendGroupGaps(files, overrides, "dxt5", 4, 0)
+	if len(gaps) != 1 || gaps[0].group != "icons" || gaps[0].gap != 0 || gaps[0].want != 2 || len(gaps[0].notes) != 1 {
+		t.Fatalf("recommendGroupGaps(dxt5) = %+v, want icons raised from 0 to 2", gaps)
+	}
+	if gaps := recommendGroupGaps(files, overrides, "bgra8", 0, 0); len(gaps) != 0 {
+		t.Fatalf("recommendGroupGaps(bgra8) = %+v, want none", gaps)
+	}
+}
+
+func TestMipSafeGap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format    string
+		level     int
+		mipmaps   int
+		mipCap    int
+		wantGap   int
+		wantLevel int
+	}{
+		{format: "bgra8", level: 0, mipCap: 11, wantGap: 0, wantLevel: 0},
+		{format: "bgra8", level: 1, mipCap: 11, wantGap: 2, wantLevel: 1},
+		{format: "bgra8", level: 3, mipCap: 11, wantGap: 8, wantLevel: 3},
+		{format: "DXT5", level: 3, mipCap: 11, wantGap: 16, wantLevel: 3},
+		{format: "dxt1", level: 5, mipmaps: 3, mipCap: 11, wantGap: 8, wantLevel: 2},
+		{format: "bgra8", level: 4, mipmaps: 1, mipCap: 11, wantGap: 0, wantLevel: 0},
+		{format: "bgra8", level: 12, mipCap: 11, wantGap: 1024, wantLevel: 10},
+		{format: "bgra8", level: 12, mipCap: 0, wantGap: 1024, wantLevel: 10},
+		{format: "bgra8", level: 12, mipCap: -1, wantGap: 4096, wantLevel: 12},
+	}
+
+	for _, tt := range tests {
+		gap, level := mipSafeGap(tt.format, tt.level, tt.mipmaps, tt.mipCap)
+		if gap != tt.wantGap || level != tt.wantLevel {
+			t.Fatalf("mipSafeGap(%s, %d, mips %d, cap %d) = %d at %d, want %d at %d",
+				tt.format, tt.level, tt.mipmaps, tt.mipCap, gap, level, tt.wantGap, tt.wantLevel)
+		}
+	}
+}
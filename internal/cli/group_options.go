@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/internal/packer"
+)
+
+// groupOverride holds packing options of one group; nil fields keep the
+// project value.
+type groupOverride struct {
+	gap     *int
+	extrude *int
+	rotate  *bool
+}
+
+// parseGroupOverrides parses --group-override values "group:key=value,..."
+// with the keys gap, rotate and extrude, keyed by normalized group name.
+// Later values for the same group and key win.
+func parseGroupOverrides(values []string) (map[string]groupOverride, error) {
+	overrides := make(map[string]groupOverride)
+	for _, value := range values {
+		group, settings, ok := strings.Cut(value, ":")
+		group = imageset.NormalizeName(strings.TrimSpace(group), false)
+		if !ok || group == "" || strings.TrimSpace(settings) == "" {
+			return nil, fmt.Errorf("invalid --group-override %q: want group:key=value,...", value)
+		}
+
+		o := overrides[group]
+		for _, setting := range strings.Split(settings, ",") {
+			key, raw, ok := strings.Cut(strings.TrimSpace(setting), "=")
+			key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+			if !ok {
+				return nil, fmt.Errorf("invalid --group-override %q: %q is not key=value", value, setting)
+			}
+
+			switch key {
+			case "gap", "extrude":
+				n, err := strconv.Atoi(raw)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid --group-override %q: %s must be a number >= 0", value, key)
+				}
+				if key == "gap" {
+					o.gap = &n
+				} else {
+					o.extrude = &n
+				}
+			case "rotate":
+				b, err := strconv.ParseBool(raw)
+				if err != nil {
+					return nil, fmt.Errorf("invalid --group-override %q: rotate must be true or false", value)
+				}
+				o.rotate = &b
+			default:
+				return nil, fmt.Errorf("invalid --group-override %q: unknown key %q (gap, rotate, extrude)", value, key)
+			}
+		}
+		overrides[group] = o
+	}
+
+	return overrides, nil
+}
+
+// resolveSpriteOptions applies group overrides to the sprites of files and
+// returns planner options of overridden sprites, the extrusion of every
+// extruded sprite, and the overridden groups no file belongs to. Extrusion
+// may not exceed the gap, or it would paint over neighbors.
+func resolveSpriteOptions(files []imageFile, overrides map[string]groupOverride, cfg atlasforge.Options, extrude int) (map[string]packer.ItemOptions, map[string]int, []string, error) {
+	items := make(map[string]packer.ItemOptions)
+	extrusion := make(map[string]int)
+	used := make(map[string]bool, len(overrides))
+	for _, f := range files {
+		itemOpts := packer.ItemOptions{Padding: cfg.Padding, AllowRotate: cfg.AllowRotate}
+		n := extrude
+
+		group := imageset.NormalizeName(f.groupName, false)
+		if o, ok := overrides[group]; ok && f.groupName != "" {
+			used[group] = true
+			if o.gap != nil {
+				itemOpts.Padding = *o.gap
+			}
+			if o.rotate != nil {
+				itemOpts.AllowRotate = *o.rotate
+			}
+			if o.extrude != nil {
+				n = *o.extrude
+			}
+			if o.gap != nil || o.rotate != nil {
+				items[f.name] = itemOpts
+			}
+		}
+
+		if n > itemOpts.Padding {
+			return nil, nil, nil, fmt.Errorf("extrude %d of %q exceeds its gap %d", n, f.name, itemOpts.Padding)
+		}
+		if n > 0 {
+			extrusion[f.name] = n
+		}
+	}
+
+	var unused []string
+	for group := range overrides {
+		if !used[group] {
+			unused = append(unused, group)
+		}
+	}
+	sort.Strings(unused)
+
+	return items, extrusion, unused, nil
+}
+
+// extrudeEdges repeats the edge pixels of the sprite occupying rect n
+// pixels outward, so filtering and mips near the edge sample sprite colors
+// instead of the transparent gap.
+func extrudeEdges(img *image.RGBA, rect image.Rectangle, n int) {
+	if n <= 0 || rect.Empty() {
+		return
+	}
+
+	b := img.Bounds()
+	outer := rect.Inset(-n).Intersect(b)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		left, right := img.RGBAAt(rect.Min.X, y), img.RGBAAt(rect.Max.X-1, y)
+		for x := outer.Min.X; x < rect.Min.X; x++ {
+			img.SetRGBA(x, y, left)
+		}
+		for x := rect.Max.X; x < outer.Max.X; x++ {
+			img.SetRGBA(x, y, right)
+		}
+	}
+
+	rowLen := (outer.Max.X - outer.Min.X) * 4
+	top := img.PixOffset(outer.Min.X, rect.Min.Y)
+	bottom := img.PixOffset(outer.Min.X, rect.Max.Y-1)
+	for y := outer.Min.Y; y < rect.Min.Y; y++ {
+		i := img.PixOffset(outer.Min.X, y)
+		copy(img.Pix[i:i+rowLen], img.Pix[top:top+rowLen])
+	}
+	for y := rect.Max.Y; y < outer.Max.Y; y++ {
+		i := img.PixOffset(outer.Min.X, y)
+		copy(img.Pix[i:i+rowLen], img.Pix[bottom:bottom+rowLen])
+	}
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+func TestParseGroupOverrides(t *testing.T) {
+	t.Parallel()
+
+	overrides, err := parseGroupOverrides([]string{"Labels:gap=4,rotate=false", "labels:extrude=2", "photos: rotate=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := overrides["labels"]
+	if labels.gap == nil || *labels.gap != 4 || labels.rotate == nil || *labels.rotate || labels.extrude == nil || *labels.extrude != 2 {
+		t.Fatalf("labels = %+v", labels)
+	}
+	if photos := overrides["photos"]; photos.rotate == nil || !*photos.rotate || photos.gap != nil {
+		t.Fatalf("photos = %+v", photos)
+	}
+
+	for _, value := range []string{"labels", ":gap=1", "labels:gap", "labels:gap=-1", "labels:rotate=maybe", "labels:scale=2"} {
+		if _, err := parseGroupOverrides([]string{value}); err == nil {
+			t.Errorf("parseGroupOverrides(%q): expected error", value)
+		}
+	}
+}
+
+func TestResolveSpriteOptions(t *testing.T) {
+	t.Parallel()
+
+	files := []imageFile{
+		{name: "ok", groupName: "labels"},
+		{name: "sky", groupName: "photos"},
+		{name: "root"},
+	}
+	overrides, err := parseGroupOverrides([]string{"labels:gap=4,rotate=false,extrude=3", "unused:gap=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := atlasforge.Options{Padding: 1, AllowRotate: true}
+
+	items, extrude, unused, err := resolveSpriteOptions(files, overrides, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items["ok"].Padding != 4 || items["ok"].AllowRotate {
+		t.Fatalf("items = %+v, want only ok with gap 4 and no rotation", items)
+	}
+	if extrude["ok"] != 3 || extrude["sky"] != 1 || extrude["root"] != 1 {
+		t.Fatalf("extrude = %v", extrude)
+	}
+	if len(unused) != 1 || unused[0] != "unused" {
+		t.Fatalf("unused = %q", unused)
+	}
+
+	if _, _, _, err := resolveSpriteOptions(files, overrides, cfg, 2); err == nil || !strings.Contains(err.Error(), "exceeds its gap") {
+		t.Fatalf("extrude over gap: got %v", err)
+	}
+}
+
+func TestExtrudeEdges(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	img.SetRGBA(3, 3, red)
+	img.SetRGBA(4, 3, red)
+	img.SetRGBA(3, 4, red)
+	img.SetRGBA(4, 4, blue)
+
+	extrudeEdges(img, image.Rect(3, 3, 5, 5), 2)
+	for _, tc := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{1, 3, red}, {6, 3, red}, {6, 4, blue}, {4, 6, blue}, {6, 6, blue}, {1, 1, red},
+		{0, 0, color.RGBA{}}, {7, 7, color.RGBA{}},
+	} {
+		if got := img.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("pixel %d,%d = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrProtectedPath reports a write under a --protect directory.
+var ErrProtectedPath = errors.New("refusing to write under a protected directory")
+
+// protectedDirsKey is the context key of the resolved --protect directories.
+type protectedDirsKey struct{}
+
+// withProtectedDirs returns ctx carrying dirs, resolved to absolute paths
+// with symlinks evaluated, for checkWritable.
+func withProtectedDirs(ctx context.Context, dirs []string) (context.Context, error) {
+	resolved := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		abs, err := resolveGuardPath(dir)
+		if err != nil {
+			return ctx, fmt.Errorf("invalid --protect %q: %w", dir, err)
+		}
+		resolved = append(resolved, abs)
+	}
+	if len(resolved) == 0 {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, protectedDirsKey{}, resolved), nil
+}
+
+// checkWritable returns ErrProtectedPath if any of paths is a protected
+// directory or lies below one. Commands call it before creating, replacing
+// or removing files.
+func checkWritable(ctx context.Context, paths ...string) error {
+	dirs, _ := ctx.Value(protectedDirsKey{}).([]string)
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		abs, err := resolveGuardPath(path)
+		if err != nil {
+			return fmt.Errorf("resolve %q: %w", path, err)
+		}
+		for _, dir := range dirs {
+			if isPathUnder(abs, dir) {
+				return fmt.Errorf("%w: %q is inside %q (--protect)", ErrProtectedPath, path, dir)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveGuardPath returns path as an absolute path with symlinks of its
+// longest existing ancestor evaluated, so links into a protected directory
+// are caught before the target exists.
+func resolveGuardPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing, rest := abs, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(resolved, rest), nil
+}
+
+// isPathUnder reports whether path equals dir or lies below it. Paths are
+// compared case-insensitively on Windows.
+func isPathUnder(path, dir string) bool {
+	if runtime.GOOS == "windows" {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckWritable(t *testing.T) {
+	root := t.TempDir()
+	game := filepath.Join(root, "DayZ")
+	work := filepath.Join(root, "work")
+	for _, dir := range []string{game, work} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(work, "game-link")
+	if err := os.Symlink(game, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	ctx, err := withProtectedDirs(context.Background(), []string{game, ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		game,
+		filepath.Join(game, "gui", "ui.edds"),
+		filepath.Join(link, "new", "ui.imageset"),
+	} {
+		if err := checkWritable(ctx, path); !errors.Is(err, ErrProtectedPath) {
+			t.Errorf("checkWritable(%q) = %v, want ErrProtectedPath", path, err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(work, "ui.edds"),
+		filepath.Join(root, "DayZ-mod", "ui.edds"),
+		"",
+	} {
+		if err := checkWritable(ctx, path); err != nil {
+			t.Errorf("checkWritable(%q) = %v, want nil", path, err)
+		}
+	}
+
+	if err := checkWritable(context.Background(), game); err != nil {
+		t.Errorf("checkWritable without --protect = %v", err)
+	}
+}
+
+func TestConvertRefusesProtectedOutput(t *testing.T) {
+	game := t.TempDir()
+	ctx, err := withProtectedDirs(context.Background(), []string{game})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &CmdConvert{}
+	cmd.Args.Input = filepath.Join(t.TempDir(), "missing.png")
+	cmd.Args.Output = filepath.Join(game, "out.png")
+	if err := cmd.ExecuteContext(ctx, nil); !errors.Is(err, ErrProtectedPath) {
+		t.Fatalf("convert into protected dir = %v, want ErrProtectedPath", err)
+	}
+}
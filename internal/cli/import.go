@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// CmdImport converts a sprite sheet layout of another atlas tool into an
+// imageset, optionally with the sheet re-encoded as EDDS.
+type CmdImport struct {
+	Args struct {
+		Input  string `positional-arg-name:"layout" description:"TexturePacker .json, Godot .tres file or directory, or .csv with name,x,y,width,height[,group]" required:"yes"`
+		Output string `positional-arg-name:"output" description:"Output directory (default: directory of the layout)"`
+	} `positional-args:"yes"`
+
+	From    string `long:"from" description:"Layout format (default: by extension, directories are Godot)" choice:"texturepacker" choice:"godot" choice:"csv"`
+	Sheet   string `short:"s" long:"sheet" description:"Sheet image (default: the image named by the layout; required for csv)"`
+	Name    string `short:"n" long:"name" description:"ImageSet name (default: sheet file name)"`
+	Path    string `short:"P" long:"edds-path" description:"Prefix path for imageset texture reference (e.g. mod/data/images)"`
+	Format  string `short:"F" long:"out-format" description:"Output format for EDDS" choice:"bgra8" choice:"dxt1" choice:"dxt5" default:"bgra8"`
+	Quality int    `short:"q" long:"quality" description:"DXT1/DXT5 quality level 1..10, 0=optimal" default:"0"`
+	Mipmaps int    `short:"x" long:"mipmaps" description:"Mipmap levels for EDDS output, 0=full chain" default:"0"`
+	EDDS    bool   `short:"e" long:"edds" description:"Also re-encode the sheet into <name>.edds, padded to power-of-two sides"`
+	Force   bool   `short:"f" long:"force" description:"Overwrite existing output files"`
+	Camel   bool   `short:"c" long:"camel-case" description:"Use CamelCase names in imageset output (default: snake_case)"`
+}
+
+// Execute runs the import command.
+func (c *CmdImport) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the import command until ctx is canceled.
+func (c *CmdImport) ExecuteContext(ctx context.Context, args []string) error {
+	return runImport(ctx, c)
+}
+
+func runImport(ctx context.Context, opts *CmdImport) error {
+	if opts.Mipmaps < 0 {
+		return fmt.Errorf("mipmaps must be >= 0")
+	}
+	if err := imageio.ValidateQualityLevel(opts.Quality); err != nil {
+		return fmt.Errorf("invalid --quality: %w", err)
+	}
+	outputFormat, err := imageio.ParseOutputFormat(opts.Format)
+	if err != nil {
+		return fmt.Errorf("invalid --out-format: %w", err)
+	}
+	if err := validateTexturePath(opts.Path); err != nil {
+		return err
+	}
+
+	format := opts.From
+	if format == "" {
+		if format, err = detectImportFormat(opts.Args.Input); err != nil {
+			return err
+		}
+	}
+	sheet, err := readImportedSheet(opts.Args.Input, format)
+	if err != nil {
+		return err
+	}
+	if opts.Sheet != "" {
+		sheet.image = opts.Sheet
+	}
+	if sheet.image == "" {
+		return fmt.Errorf("%s layout does not name a sheet image (use --sheet)", format)
+	}
+
+	width, height, err := imageio.GetImageSize(sheet.image)
+	if err != nil {
+		return fmt.Errorf("read sheet %q: %w", sheet.image, err)
+	}
+	if sheet.width > 0 && (sheet.width != width || sheet.height != height) {
+		fmt.Fprintf(os.Stderr, "Warning: layout is for a %dx%d sheet, %s is %dx%d; using the image size\n",
+			sheet.width, sheet.height, sheet.image, width, height)
+	}
+
+	var rotated, trimmed []string
+	for _, r := range sheet.rects {
+		if r.rotated {
+			rotated = append(rotated, r.name)
+		}
+		if r.trimmed {
+			trimmed = append(trimmed, r.name)
+		}
+		if r.w <= 0 || r.h <= 0 || r.x < 0 || r.y < 0 || r.x+r.w > width || r.y+r.h > height {
+			return fmt.Errorf("sprite %q at %d,%d size %dx%d is outside the %dx%d sheet", r.name, r.x, r.y, r.w, r.h, width, height)
+		}
+	}
+	if len(rotated) > 0 {
+		return fmt.Errorf("%d sprite(s) are stored rotated (%s); imagesets have no rotation, export the sheet without rotation",
+			len(rotated), listNames(rotated))
+	}
+	if len(trimmed) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d sprite(s) were trimmed by the exporter (%s); imageset sizes are the trimmed sizes\n",
+			len(trimmed), listNames(trimmed))
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(sheet.image), filepath.Ext(sheet.image))
+	}
+	outputDir := opts.Args.Output
+	if outputDir == "" {
+		outputDir = opts.Args.Input
+		if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+			outputDir = filepath.Dir(outputDir)
+		}
+	}
+	imagesetPath := filepath.Join(outputDir, name+".imageset")
+	eddsPath := filepath.Join(outputDir, name+".edds")
+
+	if err := checkWritable(ctx, outputDir); err != nil {
+		return err
+	}
+	if !opts.Force {
+		for _, path := range []string{imagesetPath, eddsPath} {
+			if path == eddsPath && !opts.EDDS {
+				continue
+			}
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("output file %q already exists (use --force)", path)
+			}
+		}
+	}
+
+	refW, refH := width, height
+	if opts.EDDS {
+		refW, refH = powerOfTwo(width), powerOfTwo(height)
+	} else if refW != powerOfTwo(refW) || refH != powerOfTwo(refH) {
+		fmt.Fprintf(os.Stderr, "Warning: sheet is %dx%d; Enfusion textures need power-of-two sides (--edds pads the sheet)\n", width, height)
+	}
+
+	doc, err := importedDocument(name, formatEddsRefPath(opts.Path, name), refW, refH, sheet.rects, opts.Camel)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	workDir, err := newProjectWorkDir(outputDir, name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = workDir.Close() }()
+
+	outputs := []string{imagesetPath}
+	if opts.EDDS {
+		img, err := imageio.Read(sheet.image)
+		if err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheet.image, err)
+		}
+		if refW != width || refH != height {
+			fmt.Printf("Padding sheet %dx%d to %dx%d\n", width, height, refW, refH)
+			padded := image.NewNRGBA(image.Rect(0, 0, refW, refH))
+			draw.Draw(padded, image.Rect(0, 0, width, height), img, img.Bounds().Min, draw.Src)
+			img = padded
+		}
+
+		err = runCancelable(ctx, func() error {
+			return imageio.WriteWithOptions(workDir.Path(eddsPath), img, &imageio.EncodeSettings{
+				Format:  outputFormat,
+				Quality: opts.Quality,
+				Mipmaps: opts.Mipmaps,
+			})
+		})
+		if err != nil {
+			if errors.Is(err, ErrInterrupted) {
+				return err
+			}
+			return fmt.Errorf("failed to write EDDS file: %w", err)
+		}
+		outputs = append(outputs, eddsPath)
+	}
+
+	if err := imageset.WriteFile(workDir.Path(imagesetPath), doc, &imageset.FormatOptions{UseCamelCaseNames: opts.Camel}); err != nil {
+		return fmt.Errorf("failed to write imageset file: %w", err)
+	}
+	if err := checkInterrupted(ctx); err != nil {
+		return err
+	}
+	if err := workDir.Commit(outputs...); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d sprites from %s (%s) as %s\n", len(sheet.rects), opts.Args.Input, format, name)
+	fmt.Printf("Outputs: %s\n", strings.Join(outputs, ", "))
+
+	return nil
+}
+
+// importedDocument builds an imageset from imported rects. Names that
+// collide after imageset name normalization are reported as errors.
+func importedDocument(name, texture string, width, height int, rects []importedRect, camel bool) (*imageset.Document, error) {
+	doc := &imageset.Document{
+		Name:     name,
+		RefSize:  imageset.Size{Width: width, Height: height},
+		Textures: []imageset.Texture{{Mpix: 1, Path: texture}},
+	}
+
+	seen := make(map[string]string, len(rects))
+	groups := make(map[string][]imageset.Image)
+	for _, r := range rects {
+		key := imageset.NormalizeName(r.group, camel) + "/" + imageset.NormalizeName(r.name, camel)
+		if prev, ok := seen[key]; ok {
+			return nil, fmt.Errorf("sprites %q and %q have the same imageset name", prev, r.name)
+		}
+		seen[key] = r.name
+
+		img := imageset.Image{
+			Name: r.name,
+			Pos:  imageset.Point{X: r.x, Y: r.y},
+			Size: imageset.Size{Width: r.w, Height: r.h},
+		}
+		if r.group == "" {
+			doc.Images = append(doc.Images, img)
+		} else {
+			groups[r.group] = append(groups[r.group], img)
+		}
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for g := range groups {
+		groupNames = append(groupNames, g)
+	}
+	sort.Strings(groupNames)
+	for _, g := range groupNames {
+		doc.Groups = append(doc.Groups, imageset.Group{Name: g, Images: groups[g]})
+	}
+
+	return doc, nil
+}
+
+// powerOfTwo returns the smallest power of two >= n.
+func powerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+
+	return p
+}
@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Layout formats read by the import command.
+const (
+	importTexturePacker = "texturepacker"
+	importGodot         = "godot"
+	importCSV           = "csv"
+)
+
+// importedSheet is a sprite sheet layout read from another atlas tool.
+type importedSheet struct {
+	// image is the sheet image path, "" when the layout does not name one.
+	image string
+	rects []importedRect
+	// width and height are the sheet size from the layout, 0 if unknown.
+	width  int
+	height int
+}
+
+// importedRect is one sprite region of a sheet.
+type importedRect struct {
+	name  string
+	group string
+	x     int
+	y     int
+	w     int
+	h     int
+	// rotated marks sprites stored rotated in the sheet; trimmed marks
+	// sprites whose transparent border was cut by the exporter.
+	rotated bool
+	trimmed bool
+}
+
+// detectImportFormat returns the layout format of path by extension; a
+// directory is read as Godot .tres files.
+func detectImportFormat(path string) (string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return importGodot, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return importTexturePacker, nil
+	case ".tres":
+		return importGodot, nil
+	case ".csv":
+		return importCSV, nil
+	}
+
+	return "", fmt.Errorf("cannot detect layout format of %q (use --from)", path)
+}
+
+// readImportedSheet reads the layout at path in the given format.
+func readImportedSheet(path, format string) (*importedSheet, error) {
+	switch format {
+	case importTexturePacker:
+		return readTexturePacker(path)
+	case importGodot:
+		return readGodotAtlas(path)
+	case importCSV:
+		return readRectCSV(path)
+	}
+
+	return nil, fmt.Errorf("unknown layout format %q", format)
+}
+
+// spriteImageExts are image file extensions spriteName strips from
+// exported sprite file names. Other dots belong to the name, e.g. "icon_v1.5".
+var spriteImageExts = map[string]bool{
+	".png": true, ".tga": true, ".tif": true, ".tiff": true, ".bmp": true,
+	".dds": true, ".edds": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".webp": true, ".psd": true,
+}
+
+// spriteName splits an exported sprite file name such as "hud/ok.png" into
+// an image name without its image extension and a group from its
+// directories.
+func spriteName(filename string) (name, group string) {
+	filename = slashPath(filename)
+	if ext := filepath.Ext(filename); spriteImageExts[strings.ToLower(ext)] {
+		filename = strings.TrimSuffix(filename, ext)
+	}
+
+	return splitSpriteGroup(filename)
+}
+
+// splitSpriteGroup splits a slash separated sprite path into its last
+// element and a group from the directories before it.
+func splitSpriteGroup(path string) (name, group string) {
+	name = path
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		group = strings.ReplaceAll(strings.Trim(name[:i], "/"), "/", "_")
+		name = name[i+1:]
+	}
+
+	return name, group
+}
+
+// tpRect is a TexturePacker rectangle.
+type tpRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// tpFrame is a TexturePacker frame of the JSON (Hash) and JSON (Array)
+// data formats; Filename is set only in arrays.
+type tpFrame struct {
+	Filename string `json:"filename"`
+	Frame    tpRect `json:"frame"`
+	Rotated  bool   `json:"rotated"`
+	Trimmed  bool   `json:"trimmed"`
+}
+
+// readTexturePacker reads TexturePacker JSON in hash or array form.
+func readTexturePacker(path string) (*importedSheet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Frames json.RawMessage `json:"frames"`
+		Meta   struct {
+			Image string `json:"image"`
+			Size  tpRect `json:"size"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+
+	var frames []tpFrame
+	switch trimmed := bytes.TrimSpace(doc.Frames); {
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		var byName map[string]tpFrame
+		if err := json.Unmarshal(trimmed, &byName); err != nil {
+			return nil, fmt.Errorf("parse frames of %q: %w", path, err)
+		}
+		for name, frame := range byName {
+			frame.Filename = name
+			frames = append(frames, frame)
+		}
+		sort.Slice(frames, func(i, j int) bool { return frames[i].Filename < frames[j].Filename })
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		if err := json.Unmarshal(trimmed, &frames); err != nil {
+			return nil, fmt.Errorf("parse frames of %q: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%q has no TexturePacker frames", path)
+	}
+
+	sheet := &importedSheet{width: doc.Meta.Size.W, height: doc.Meta.Size.H}
+	if doc.Meta.Image != "" {
+		sheet.image = filepath.Join(filepath.Dir(path), filepath.FromSlash(slashPath(doc.Meta.Image)))
+	}
+	for _, f := range frames {
+		name, group := spriteName(f.Filename)
+		sheet.rects = append(sheet.rects, importedRect{
+			name:    name,
+			group:   group,
+			x:       f.Frame.X,
+			y:       f.Frame.Y,
+			w:       f.Frame.W,
+			h:       f.Frame.H,
+			rotated: f.Rotated,
+			trimmed: f.Trimmed,
+		})
+	}
+
+	return sheet, nil
+}
+
+var (
+	// godotExtResource matches Godot 3 and 4 ext_resource headers.
+	godotExtResource = regexp.MustCompile(`^\[ext_resource\b.*\]$`)
+	godotAttr        = regexp.MustCompile(`(\w+)=("[^"]*"|[^\s\]]+)`)
+	godotAtlasRef    = regexp.MustCompile(`^atlas\s*=\s*ExtResource\(\s*"?([^")\s]+)"?\s*\)`)
+	godotRegion      = regexp.MustCompile(`^region\s*=\s*Rect2\(([^)]*)\)`)
+)
+
+// readGodotAtlas reads AtlasTexture resources: one .tres file, or every
+// .tres file below a directory with subdirectories as groups. All regions
+// must reference the same sheet texture.
+func readGodotAtlas(path string) (*importedSheet, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type tresFile struct{ path, group string }
+	var files []tresFile
+	if !info.IsDir() {
+		files = append(files, tresFile{path: path})
+	} else {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".tres") {
+				return err
+			}
+			rel, _ := filepath.Rel(path, filepath.Dir(p))
+			group := ""
+			if rel != "." {
+				group = strings.ReplaceAll(filepath.ToSlash(rel), "/", "_")
+			}
+			files = append(files, tresFile{path: p, group: group})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sheet := &importedSheet{}
+	for _, f := range files {
+		texture, rect, ok, err := readGodotTres(f.path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if sheet.image != "" && texture != sheet.image {
+			return nil, fmt.Errorf("%q uses %q, other regions use %q; import one sheet at a time", f.path, texture, sheet.image)
+		}
+		sheet.image = texture
+
+		rect.name = strings.TrimSuffix(filepath.Base(f.path), filepath.Ext(f.path))
+		rect.group = f.group
+		sheet.rects = append(sheet.rects, rect)
+	}
+	if len(sheet.rects) == 0 {
+		return nil, fmt.Errorf("no AtlasTexture resources found in %q", path)
+	}
+
+	return sheet, nil
+}
+
+// readGodotTres reads an AtlasTexture .tres file and returns the resolved
+// atlas texture path and region; ok is false for other resource types.
+func readGodotTres(path string) (texture string, rect importedRect, ok bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", rect, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	resources := make(map[string]string)
+	var atlasID, region string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "[gd_resource"):
+			ok = strings.Contains(line, `type="AtlasTexture"`)
+		case godotExtResource.MatchString(line):
+			attrs := make(map[string]string)
+			for _, m := range godotAttr.FindAllStringSubmatch(line, -1) {
+				attrs[m[1]] = strings.Trim(m[2], `"`)
+			}
+			resources[attrs["id"]] = attrs["path"]
+		case godotAtlasRef.MatchString(line):
+			atlasID = godotAtlasRef.FindStringSubmatch(line)[1]
+		case godotRegion.MatchString(line):
+			region = godotRegion.FindStringSubmatch(line)[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", rect, false, fmt.Errorf("read %q: %w", path, err)
+	}
+	if !ok {
+		return "", rect, false, nil
+	}
+
+	resPath, found := resources[atlasID]
+	if !found || region == "" {
+		return "", rect, false, fmt.Errorf("%q: AtlasTexture without an external atlas texture and region", path)
+	}
+
+	var values [4]int
+	parts := strings.Split(region, ",")
+	if len(parts) != 4 {
+		return "", rect, false, fmt.Errorf("%q: invalid region Rect2(%s)", path, region)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v != math.Trunc(v) {
+			return "", rect, false, fmt.Errorf("%q: region Rect2(%s) is not whole pixels", path, region)
+		}
+		values[i] = int(v)
+	}
+	rect = importedRect{x: values[0], y: values[1], w: values[2], h: values[3]}
+
+	return resolveGodotPath(path, resPath), rect, true, nil
+}
+
+// resolveGodotPath resolves a res:// path against the Godot project root,
+// the nearest parent of from holding project.godot, or the directory of from.
+func resolveGodotPath(from, resPath string) string {
+	rel, isRes := strings.CutPrefix(resPath, "res://")
+	dir := filepath.Dir(from)
+	if !isRes {
+		return filepath.Join(dir, filepath.FromSlash(resPath))
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err == nil {
+		for d := abs; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(filepath.Join(d, "project.godot")); err == nil {
+				return filepath.Join(d, filepath.FromSlash(rel))
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(rel))
+}
+
+// readRectCSV reads rows of name,x,y,width,height[,group]. A first row
+// whose coordinates are not numbers is taken as a header.
+func readRectCSV(path string) (*importedSheet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	sheet := &importedSheet{}
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %q: %w", path, err)
+		}
+		if len(row) < 5 || len(row) > 6 {
+			return nil, fmt.Errorf("%s:%d: want name,x,y,width,height[,group], got %d fields", path, line, len(row))
+		}
+
+		var values [4]int
+		var numErr error
+		for i := range values {
+			if values[i], numErr = strconv.Atoi(strings.TrimSpace(row[i+1])); numErr != nil {
+				break
+			}
+		}
+		if numErr != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, line, numErr)
+		}
+
+		// CSV names are sprite names, not file names: keep every dot.
+		name, group := splitSpriteGroup(slashPath(strings.TrimSpace(row[0])))
+		if len(row) == 6 && strings.TrimSpace(row[5]) != "" {
+			group = strings.TrimSpace(row[5])
+		}
+		sheet.rects = append(sheet.rects, importedRect{
+			name: name, group: group,
+			x: values[0], y: values[1], w: values[2], h: values[3],
+		})
+	}
+	if len(sheet.rects) == 0 {
+		return nil, fmt.Errorf("no rects found in %q", path)
+	}
+
+	return sheet, nil
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadTexturePacker(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	hash := filepath.Join(dir, "hash.json")
+	writeTestFile(t, hash, `{"frames": {
+		"hud/ok.png": {"frame": {"x": 2, "y": 4, "w": 16, "h": 8}, "rotated": false, "trimmed": true},
+		"cancel.png": {"frame": {"x": 20, "y": 4, "w": 8, "h": 8}, "rotated": true, "trimmed": false}
+	}, "meta": {"image": "sheets/ui.png", "size": {"w": 64, "h": 32}}}`)
+	array := filepath.Join(dir, "array.json")
+	writeTestFile(t, array, `{"frames": [
+		{"filename": "cancel.png", "frame": {"x": 20, "y": 4, "w": 8, "h": 8}, "rotated": true},
+		{"filename": "hud/ok.png", "frame": {"x": 2, "y": 4, "w": 16, "h": 8}, "trimmed": true}
+	], "meta": {"image": "sheets/ui.png", "size": {"w": 64, "h": 32}}}`)
+
+	for _, path := range []string{hash, array} {
+		sheet, err := readImportedSheet(path, importTexturePacker)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if sheet.image != filepath.Join(dir, "sheets", "ui.png") || sheet.width != 64 || sheet.height != 32 {
+			t.Fatalf("%s: sheet %q %dx%d", path, sheet.image, sheet.width, sheet.height)
+		}
+		want := []importedRect{
+			{name: "cancel", x: 20, y: 4, w: 8, h: 8, rotated: true},
+			{name: "ok", group: "hud", x: 2, y: 4, w: 16, h: 8, trimmed: true},
+		}
+		if len(sheet.rects) != len(want) {
+			t.Fatalf("%s: got %d rects", path, len(sheet.rects))
+		}
+		for i := range want {
+			if sheet.rects[i] != want[i] {
+				t.Fatalf("%s: rect %d = %+v, want %+v", path, i, sheet.rects[i], want[i])
+			}
+		}
+	}
+}
+
+func TestReadGodotAtlas(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "project.godot"), "")
+	writeTestFile(t, filepath.Join(root, "ui", "atlas", "icons", "ok.tres"), `[gd_resource type="AtlasTexture" load_steps=2 format=3]
+
+[ext_resource type="Texture2D" path="res://ui/ui.png" id="1_ab"]
+
+[resource]
+atlas = ExtResource("1_ab")
+region = Rect2(4, 8, 16, 16)
+`)
+	writeTestFile(t, filepath.Join(root, "ui", "atlas", "cancel.tres"), `[gd_resource type="AtlasTexture" load_steps=2 format=2]
+
+[ext_resource path="res://ui/ui.png" type="Texture" id=1]
+
+[resource]
+atlas = ExtResource( 1 )
+region = Rect2( 24, 8, 12.0, 16 )
+`)
+	writeTestFile(t, filepath.Join(root, "ui", "atlas", "theme.tres"), `[gd_resource type="Theme" format=3]
+`)
+
+	sheet, err := readImportedSheet(filepath.Join(root, "ui", "atlas"), importGodot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheet.image != filepath.Join(root, "ui", "ui.png") {
+		t.Fatalf("sheet image = %q", sheet.image)
+	}
+	want := []importedRect{
+		{name: "cancel", x: 24, y: 8, w: 12, h: 16},
+		{name: "ok", group: "icons", x: 4, y: 8, w: 16, h: 16},
+	}
+	if len(sheet.rects) != len(want) {
+		t.Fatalf("got %+v", sheet.rects)
+	}
+	for i := range want {
+		if sheet.rects[i] != want[i] {
+			t.Fatalf("rect %d = %+v, want %+v", i, sheet.rects[i], want[i])
+		}
+	}
+
+	writeTestFile(t, filepath.Join(root, "ui", "atlas", "other.tres"), `[gd_resource type="AtlasTexture" format=3]
+[ext_resource type="Texture2D" path="res://other.png" id="1"]
+[resource]
+atlas = ExtResource("1")
+region = Rect2(0, 0, 4, 4)
+`)
+	if _, err := readImportedSheet(filepath.Join(root, "ui", "atlas"), importGodot); err == nil || !strings.Contains(err.Error(), "one sheet") {
+		t.Fatalf("mixed sheets error = %v", err)
+	}
+}
+
+func TestReadRectCSV(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rects.csv")
+	writeTestFile(t, path, "name,x,y,width,height,group\n# comment\nicon_v1.5, 1, 2, 3, 4\ncancel,5,6,7,8,hud\n")
+
+	sheet, err := readImportedSheet(path, importCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []importedRect{
+		{name: "icon_v1.5", x: 1, y: 2, w: 3, h: 4},
+		{name: "cancel", group: "hud", x: 5, y: 6, w: 7, h: 8},
+	}
+	if len(sheet.rects) != len(want) || sheet.rects[0] != want[0] || sheet.rects[1] != want[1] {
+		t.Fatalf("got %+v, want %+v", sheet.rects, want)
+	}
+
+	writeTestFile(t, path, "ok,1,2,3,4\nbad,1,x,3,4\n")
+	if _, err := readImportedSheet(path, importCSV); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Fatalf("bad row error = %v", err)
+	}
+}
+
+func TestImportedDocumentNameCollision(t *testing.T) {
+	t.Parallel()
+
+	doc, err := importedDocument("ui", "ui.edds", 64, 64, []importedRect{
+		{name: "ok", group: "hud", w: 1, h: 1},
+		{name: "ok", w: 1, h: 1},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Images) != 1 || len(doc.Groups) != 1 || doc.Groups[0].Name != "hud" {
+		t.Fatalf("got %+v", doc)
+	}
+
+	_, err = importedDocument("ui", "ui.edds", 64, 64, []importedRect{
+		{name: "Icon-A", w: 1, h: 1},
+		{name: "icon_a", w: 1, h: 1},
+	}, false)
+	if err == nil {
+		t.Fatal("expected a name collision error")
+	}
+}
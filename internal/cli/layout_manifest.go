@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset"
+)
+
+// layoutManifest is the <name>.layout.json metadata written by
+// --layout-only for a tool that renders the atlas texture later, and by
+// --layout-json next to the atlas for unpack.
+type layoutManifest struct {
+	Name string `json:"name"`
+	// Texture is the texture path referenced by the imageset.
+	Texture string         `json:"texture"`
+	Sprites []layoutSprite `json:"sprites"`
+	Width   int            `json:"width"`
+	Height  int            `json:"height"`
+	Gap     int            `json:"gap"`
+}
+
+// layoutSprite is one placed sprite. Width and Height are the size in the
+// atlas before rotation, after --max-input-side downscaling. A rotated
+// sprite is turned 90 degrees clockwise and covers Height x Width pixels.
+type layoutSprite struct {
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+	// Source is the input file relative to the input directory. Tiles of
+	// --tile-oversized name their source; offsets are in <name>.tiles.json.
+	Source string `json:"source"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// Gap is the empty margin kept around the sprite and Extrude how far
+	// its edge pixels are repeated into it.
+	Gap     int  `json:"gap"`
+	Extrude int  `json:"extrude,omitempty"`
+	Rotated bool `json:"rotated,omitempty"`
+}
+
+// newLayoutManifest describes layout for files packed from inputDir with
+// the project gap and per-sprite options of lo. Names are written as the
+// imageset writes them.
+func newLayoutManifest(name, texture, inputDir string, gap int, lo layoutOptions, files []imageFile, layout *atlasforge.Layout, camel bool) layoutManifest {
+	placements := make(map[string]atlasforge.Placement, len(layout.Placements))
+	for _, p := range layout.Placements {
+		placements[p.ID] = p
+	}
+
+	m := layoutManifest{
+		Name:    name,
+		Texture: texture,
+		Width:   layout.Width,
+		Height:  layout.Height,
+		Gap:     gap,
+		Sprites: make([]layoutSprite, 0, len(files)),
+	}
+	for _, f := range files {
+		p := placements[f.name]
+		source := f.path
+		if rel, err := filepath.Rel(inputDir, f.path); err == nil {
+			source = filepath.ToSlash(rel)
+		}
+
+		sprite := layoutSprite{
+			Name:    imageset.NormalizeName(f.name, camel),
+			Source:  source,
+			X:       p.X,
+			Y:       p.Y,
+			Width:   p.Width,
+			Height:  p.Height,
+			Gap:     lo.padding(f.name, gap),
+			Extrude: lo.extrude[f.name],
+			Rotated: p.Rotated,
+		}
+		if f.groupName != "" {
+			sprite.Group = imageset.NormalizeName(f.groupName, camel)
+		}
+		m.Sprites = append(m.Sprites, sprite)
+	}
+
+	return m
+}
+
+// readLayoutManifest reads layout metadata written by pack.
+func readLayoutManifest(path string) (*layoutManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read layout metadata: %w", err)
+	}
+
+	var m layoutManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse layout metadata %q: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+// rotatedPositions returns atlas positions of rotated sprites. unpack keys
+// by position, so renamed and aliased entries of a sprite match too.
+func rotatedPositions(m *layoutManifest) map[image.Point]bool {
+	rotated := make(map[image.Point]bool)
+	for _, s := range m.Sprites {
+		if s.Rotated {
+			rotated[image.Pt(s.X, s.Y)] = true
+		}
+	}
+
+	return rotated
+}
+
+// writeLayoutManifest writes layout metadata as JSON.
+func writeLayoutManifest(path string, m layoutManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
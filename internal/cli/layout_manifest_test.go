@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+func TestLayoutManifestMatchesPackedLayout(t *testing.T) {
+	t.Parallel()
+
+	input := filepath.Join("art", "ui")
+	var files []imageFile
+	var sprites []atlasforge.Sprite
+	for i, size := range [][2]int{{40, 12}, {16, 16}, {8, 60}, {33, 21}} {
+		f := imageFile{
+			image:  image.NewNRGBA(image.Rect(0, 0, size[0], size[1])),
+			path:   filepath.Join(input, "icons", fmt.Sprintf("IconSprite%d.png", i)),
+			name:   fmt.Sprintf("IconSprite%d", i),
+			width:  size[0],
+			height: size[1],
+		}
+		if i%2 == 1 {
+			f.groupName = "HUD-Icons"
+		}
+		files = append(files, f)
+		sprites = append(sprites, atlasforge.Sprite{ID: f.name, Width: f.width, Height: f.height, Image: f.image})
+	}
+
+	cfg := atlasforge.DefaultOptions()
+	cfg.MinSize = 64
+	cfg.Padding = 2
+	cfg.AllowRotate = true
+	lo := layoutOptions{blockAlign: 4}
+
+	planned, err := planAtlas(sprites, cfg, lo)
+	if err != nil {
+		t.Fatalf("planAtlas: %v", err)
+	}
+	packed, err := packAtlas(sprites, cfg, lo)
+	if err != nil {
+		t.Fatalf("packAtlas: %v", err)
+	}
+	if !reflect.DeepEqual(*planned, packed.Layout) {
+		t.Fatalf("planned layout %+v differs from packed %+v", *planned, packed.Layout)
+	}
+
+	m := newLayoutManifest("ui", "mod/ui.edds", input, cfg.Padding, lo, files, planned, false)
+	if m.Width != packed.Layout.Width || m.Height != packed.Layout.Height || len(m.Sprites) != len(files) {
+		t.Fatalf("manifest %dx%d with %d sprites", m.Width, m.Height, len(m.Sprites))
+	}
+	for i, s := range m.Sprites {
+		p := packed.Layout.Placements[indexOfPlacement(packed.Layout.Placements, files[i].name)]
+		if s.X != p.X || s.Y != p.Y || s.Width != p.Width || s.Height != p.Height || s.Rotated != p.Rotated || s.Gap != cfg.Padding {
+			t.Fatalf("sprite %s = %+v, want placement %+v", s.Name, s, p)
+		}
+		if want := fmt.Sprintf("icons/IconSprite%d.png", i); s.Source != want {
+			t.Fatalf("sprite %s source = %q, want %q", s.Name, s.Source, want)
+		}
+	}
+	if m.Sprites[0].Name != "iconsprite0" || m.Sprites[0].Group != "" || m.Sprites[1].Group != "hud_icons" {
+		t.Fatalf("names not normalized like the imageset: %+v", m.Sprites[:2])
+	}
+}
+
+func indexOfPlacement(placements []atlasforge.Placement, id string) int {
+	for i, p := range placements {
+		if p.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestUnrotateRestoresRenderedSprite(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewRGBA(image.Rect(0, 0, 5, 3))
+	for y := range 3 {
+		for x := range 5 {
+			src.Pix[src.PixOffset(x, y)] = uint8(y*5 + x + 1)
+			src.Pix[src.PixOffset(x, y)+3] = 255
+		}
+	}
+
+	layout := &atlasforge.Layout{
+		Width:      8,
+		Height:     8,
+		Placements: []atlasforge.Placement{{ID: "arrow", X: 2, Y: 1, Width: 5, Height: 3, Rotated: true}},
+	}
+	img, err := atlasforge.Render(layout, []atlasforge.Source{{ID: "arrow", Image: src}})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	m := &layoutManifest{Sprites: []layoutSprite{{Name: "arrow", X: 2, Y: 1, Width: 5, Height: 3, Rotated: true}}}
+	rotated := rotatedPositions(m)
+	if !rotated[image.Pt(2, 1)] || len(rotated) != 1 {
+		t.Fatalf("rotatedPositions = %v", rotated)
+	}
+
+	sub, err := crop(img, 2, 1, 3, 5)
+	if err != nil {
+		t.Fatalf("crop: %v", err)
+	}
+	got := unrotate(sub)
+	if got.Bounds() != src.Bounds() || !reflect.DeepEqual(got.Pix, src.Pix) {
+		t.Fatalf("unrotated sprite %v differs from source %v", got.Pix, src.Pix)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// maxLintNames limits file names listed per lint finding.
+const maxLintNames = 5
+
+// lintSources reports inputs that mix bit depths, color space tags or
+// alpha modes. Mixed sources make sprites of one atlas look inconsistent
+// in brightness, banding or edge color. Opaque sources do not count
+// towards alpha mixing.
+func lintSources(files []imageFile) []string {
+	var findings []string
+	check := func(what string, value func(f imageFile) string) {
+		byValue := make(map[string][]string)
+		for _, f := range files {
+			if v := value(f); v != "" {
+				byValue[v] = append(byValue[v], f.path)
+			}
+		}
+		if len(byValue) < 2 {
+			return
+		}
+
+		values := make([]string, 0, len(byValue))
+		for v := range byValue {
+			values = append(values, v)
+		}
+		// Most common first, so the listed files are the odd ones out.
+		sort.Slice(values, func(i, j int) bool {
+			if len(byValue[values[i]]) != len(byValue[values[j]]) {
+				return len(byValue[values[i]]) > len(byValue[values[j]])
+			}
+			return values[i] < values[j]
+		})
+
+		parts := make([]string, 0, len(values))
+		for i, v := range values {
+			part := fmt.Sprintf("%s (%d)", v, len(byValue[v]))
+			if i > 0 {
+				part += ": " + listNames(byValue[v])
+			}
+			parts = append(parts, part)
+		}
+		findings = append(findings, fmt.Sprintf("inputs mix %s: %s", what, strings.Join(parts, "; ")))
+	}
+
+	check("bit depths", func(f imageFile) string { return fmt.Sprintf("%d-bit", f.info.BitDepth) })
+	check("color space tags", func(f imageFile) string { return f.info.ColorTag })
+	check("alpha modes", func(f imageFile) string {
+		if f.info.Alpha == imageio.AlphaNone {
+			return ""
+		}
+		return string(f.info.Alpha)
+	})
+
+	return findings
+}
+
+// listNames joins up to maxLintNames sorted names and counts the rest.
+func listNames(names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	if len(sorted) <= maxLintNames {
+		return strings.Join(sorted, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(sorted[:maxLintNames], ", "), len(sorted)-maxLintNames)
+}
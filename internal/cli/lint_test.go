@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+func TestLintSources(t *testing.T) {
+	t.Parallel()
+
+	straight := imageio.SourceInfo{ColorTag: imageio.ColorTagSRGB, Alpha: imageio.AlphaStraight, BitDepth: 8}
+	files := []imageFile{
+		{path: "a.png", info: straight},
+		{path: "b.png", info: straight},
+		{path: "c.png", info: imageio.SourceInfo{ColorTag: imageio.ColorTagSRGB, Alpha: imageio.AlphaNone, BitDepth: 8}},
+	}
+	if findings := lintSources(files); len(findings) != 0 {
+		t.Fatalf("consistent inputs reported: %q", findings)
+	}
+
+	files = append(files,
+		imageFile{path: "d.tga", info: imageio.SourceInfo{ColorTag: imageio.ColorTagUntagged, Alpha: imageio.AlphaPremultiplied, BitDepth: 8}},
+		imageFile{path: "e.png", info: imageio.SourceInfo{ColorTag: imageio.ColorTagSRGB, Alpha: imageio.AlphaStraight, BitDepth: 16}},
+	)
+	findings := lintSources(files)
+	if len(findings) != 3 {
+		t.Fatalf("got findings %q, want 3", findings)
+	}
+	for i, odd := range []string{"e.png", "d.tga", "d.tga"} {
+		if !strings.Contains(findings[i], odd) || strings.Contains(findings[i], "a.png") {
+			t.Fatalf("finding %q should list only %s", findings[i], odd)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockPollInterval is the delay between attempts to take a busy lock.
+const lockPollInterval = 100 * time.Millisecond
+
+// outputLock is an advisory lock on one output set (imageset + edds).
+// It is a lock file created exclusively, which works the same on every
+// platform and on network shares where flock semantics are unreliable.
+type outputLock struct {
+	path string
+}
+
+// acquireOutputLock takes the lock for name in outputDir. With wait > 0 it
+// polls until the lock is free or wait elapses; otherwise it fails fast.
+func acquireOutputLock(ctx context.Context, outputDir, name string, wait time.Duration) (*outputLock, error) {
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(outputDir, "."+name+".lock")
+	owner := fmt.Sprintf("pid=%d host=%s since=%s\n", os.Getpid(), hostname(), time.Now().UTC().Format(time.RFC3339))
+	deadline := time.Now().Add(wait)
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, writeErr := f.WriteString(owner)
+			closeErr := f.Close()
+			if writeErr != nil || closeErr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file %q: %w", path, errors.Join(writeErr, closeErr))
+			}
+
+			return &outputLock{path: path}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %q: %w", path, err)
+		}
+
+		if wait <= 0 || time.Now().After(deadline) {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf(
+				"outputs %q are locked by another process (%s); use --lock-wait to wait, or remove %q if no other process is running",
+				filepath.Join(outputDir, name), strings.TrimSpace(string(holder)), path,
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrInterrupted
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Release removes the lock file.
+func (l *outputLock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file %q: %w", l.path, err)
+	}
+
+	return nil
+}
+
+// hostname returns the local host name or "unknown".
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "unknown"
+	}
+
+	return name
+}
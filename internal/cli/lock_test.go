@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOutputLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first, err := acquireOutputLock(ctx, dir, "ui", 0)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	if _, err := acquireOutputLock(ctx, dir, "ui", 0); err == nil {
+		t.Fatal("second acquire succeeded while lock is held")
+	}
+	if _, err := acquireOutputLock(ctx, dir, "icons", 0); err != nil {
+		t.Fatalf("acquire other output set: %v", err)
+	}
+
+	go func() {
+		time.Sleep(2 * lockPollInterval)
+		_ = first.Release()
+	}()
+
+	second, err := acquireOutputLock(ctx, dir, "ui", 5*time.Second)
+	if err != nil {
+		t.Fatalf("acquire with wait: %v", err)
+	}
+	if err := second.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/woozymasta/imageset"
+)
+
+// validateNamespace checks that a --namespace value is usable as a name
+// prefix.
+func validateNamespace(namespace string) error {
+	for _, r := range namespace {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return fmt.Errorf("invalid --namespace %q: only letters, digits and '_' are allowed", namespace)
+		}
+	}
+
+	return nil
+}
+
+// namespaced returns name prefixed with namespace and '_', or name as is
+// without a namespace.
+func namespaced(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "_" + name
+}
+
+// spriteNamespace returns the prefix namespace of sprite names, empty
+// unless --namespace-sprites is set.
+func (c *CmdPack) spriteNamespace() string {
+	if !c.NamespaceSprites {
+		return ""
+	}
+
+	return c.Namespace
+}
+
+// namespaceSprites prefixes every image name in doc with namespace.
+func namespaceSprites(doc *imageset.Document, namespace string) {
+	if namespace == "" {
+		return
+	}
+
+	for i := range doc.Images {
+		doc.Images[i].Name = namespaced(namespace, doc.Images[i].Name)
+	}
+	for g := range doc.Groups {
+		for i := range doc.Groups[g].Images {
+			doc.Groups[g].Images[i].Name = namespaced(namespace, doc.Groups[g].Images[i].Name)
+		}
+	}
+}
+
+// readNameList reads names one per line; blank lines and lines starting
+// with '#' are ignored.
+func readNameList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read name list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read name list %q: %w", path, err)
+	}
+
+	return names, nil
+}
+
+// vanillaCollision returns the entry of vanilla that name collides with.
+// The game compares imageset names case-insensitively, so names are
+// compared after normalization and case folding.
+func vanillaCollision(name string, vanilla []string) (string, bool) {
+	key := imageset.NormalizeName(name, false)
+	for _, v := range vanilla {
+		if strings.EqualFold(key, imageset.NormalizeName(v, false)) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/woozymasta/imageset"
+)
+
+func TestNamespaceSprites(t *testing.T) {
+	t.Parallel()
+
+	doc := &imageset.Document{
+		Images: []imageset.Image{{Name: "icon_ok"}},
+		Groups: []imageset.Group{{Name: "hud", Images: []imageset.Image{{Name: "icon_cancel"}}}},
+	}
+	namespaceSprites(doc, "mymod")
+	if doc.Images[0].Name != "mymod_icon_ok" || doc.Groups[0].Images[0].Name != "mymod_icon_cancel" {
+		t.Fatalf("got %q and %q, want mymod_ prefixes", doc.Images[0].Name, doc.Groups[0].Images[0].Name)
+	}
+	if doc.Groups[0].Name != "hud" {
+		t.Fatalf("group renamed to %q", doc.Groups[0].Name)
+	}
+
+	for _, ns := range []string{"my-mod", "mod/x", "a b"} {
+		if err := validateNamespace(ns); err == nil {
+			t.Errorf("validateNamespace(%q): expected error", ns)
+		}
+	}
+}
+
+func TestVanillaCollision(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "vanilla.txt")
+	writeTestFile(t, path, "# DayZ imagesets\ndayz_gui\n\n  dayz_inventory  \n")
+	vanilla, err := readNameList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vanilla) != 2 {
+		t.Fatalf("got %q, want 2 names", vanilla)
+	}
+
+	if v, ok := vanillaCollision("DayZ_Inventory", vanilla); !ok || v != "dayz_inventory" {
+		t.Fatalf("got %q, %v, want collision with dayz_inventory", v, ok)
+	}
+	if _, ok := vanillaCollision(namespaced("mymod", "dayz_gui"), vanilla); ok {
+		t.Fatal("namespaced name must not collide")
+	}
+}
+
+func TestResolveFrozenNamespace(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "old.imageset")
+	doc := &imageset.Document{
+		Name:    "mymod_old",
+		RefSize: imageset.Size{Width: 64, Height: 64},
+		Images: []imageset.Image{
+			{Name: "mymod_icon_a", Pos: imageset.Point{X: 4, Y: 8}, Size: imageset.Size{Width: 16, Height: 16}},
+		},
+	}
+	if err := imageset.WriteFile(path, doc, nil); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	files := []imageFile{{name: "icon_a", width: 16, height: 16}}
+	frozen, err := resolveFrozen(&PackPackingFlags{FreezeFrom: path}, "", files, "mymod")
+	if err != nil {
+		t.Fatalf("resolveFrozen: %v", err)
+	}
+	if len(frozen.placements) != 1 || frozen.placements[0].X != 4 {
+		t.Fatalf("got %+v, want icon_a at 4,8", frozen.placements)
+	}
+}
@@ -1,44 +1,73 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
+	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/woozymasta/atlasforge"
 	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/edds"
 	"github.com/woozymasta/imageset-packer/internal/imageio"
+	"github.com/woozymasta/imageset-packer/internal/packer"
 	"golang.org/x/image/draw"
 )
 
 // PackPackingFlags defines atlas packing parameters.
 type PackPackingFlags struct {
-	Rule          string  `short:"r" long:"rule" description:"Packing rule" default:"bl" choice:"bssf" choice:"blsf" choice:"baf" choice:"bl" choice:"cp" choice:"ff" yaml:"rule"`
-	OutputFormat  string  `short:"F" long:"out-format" description:"Output format for DDS/EDDS" choice:"bgra8" choice:"dxt1" choice:"dxt5" default:"bgra8" yaml:"out_format"`
-	MinSize       int     `short:"m" long:"min-size" description:"Minimum texture size (power of 2)" default:"256" yaml:"min_size"`
-	MaxSize       int     `short:"M" long:"max-size" description:"Maximum texture size (power of 2)" default:"4096" yaml:"max_size"`
-	Gap           int     `short:"g" long:"gap" description:"Gap between images" default:"0" yaml:"gap"`
-	Quality       int     `short:"q" long:"quality" description:"DXT1/DXT5 quality level 1..10, 0=optimal" default:"0" yaml:"quality"`
-	Mipmaps       int     `short:"x" long:"mipmaps" description:"Mipmap levels for DDS/EDDS output, 0=full chain" default:"0" yaml:"mipmaps"`
-	AspectPenalty float64 `short:"a" long:"aspect-penalty" description:"Aspect penalty for non-square textures" default:"0.25" yaml:"aspect_penalty"`
-	PreferHeight  bool    `short:"p" long:"prefer-height" description:"Prefer height over width for aspect ratio" yaml:"prefer_height"`
-	ForceSquare   bool    `short:"S" long:"force-square" description:"Force square texture" yaml:"force_square"`
-	AllowRotate   bool    `short:"R" long:"rotate" description:"Allow 90-degree rotation for better packing" yaml:"rotate"`
+	Rule            string   `short:"r" long:"rule" description:"Packing rule" default:"bl" choice:"bssf" choice:"blsf" choice:"baf" choice:"bl" choice:"cp" choice:"ff" yaml:"rule"`
+	OutputFormat    string   `short:"F" long:"out-format" description:"Output format for DDS/EDDS" choice:"bgra8" choice:"dxt1" choice:"dxt5" default:"bgra8" yaml:"out_format"`
+	MinSize         int      `short:"m" long:"min-size" description:"Minimum texture size (power of 2)" default:"256" yaml:"min_size"`
+	MaxSize         int      `short:"M" long:"max-size" description:"Maximum texture size (power of 2)" default:"4096" yaml:"max_size"`
+	Gap             int      `short:"g" long:"gap" description:"Gap between images" default:"0" yaml:"gap"`
+	Extrude         int      `long:"extrude" description:"Repeat sprite edge pixels N pixels into the gap (at most --gap)" default:"0" yaml:"extrude"`
+	AutoGap         bool     `long:"auto-gap" description:"Raise the gap to the smallest value free of DXT block and mip bleeding instead of warning" yaml:"auto_gap"`
+	MipSafe         int      `long:"mip-safe" description:"Raise the gap so sprites do not bleed together down to mip level N (0=off)" default:"0" yaml:"mip_safe"`
+	BlockAlign      int      `long:"block-align" description:"Grow sprite slots to multiples of N pixels (4 = DXT blocks), 0=format default" default:"0" yaml:"block_align"`
+	Quality         int      `short:"q" long:"quality" description:"DXT1/DXT5 quality level 1..10, 0=optimal" default:"0" yaml:"quality"`
+	Mipmaps         int      `short:"x" long:"mipmaps" description:"Mipmap levels for DDS/EDDS output, 0=full chain" default:"0" yaml:"mipmaps"`
+	MipCap          int      `long:"mip-cap" description:"Maximum levels of a full mip chain (--mipmaps 0): 0=11 like the Enfusion tools, -1=no cap" default:"0" yaml:"mip_cap"`
+	MinMipSize      int      `long:"min-mip-size" description:"Stop the mip chain before a level with a side below N (e.g. 4 for BCn blocks), 0=down to 1x1" default:"0" yaml:"min_mip_size"`
+	Compression     string   `long:"compression" description:"EDDS block compression: hc=smallest, balanced=hc for the base mip only, fast, none" choice:"hc" choice:"balanced" choice:"fast" choice:"none" default:"hc" yaml:"compression"`
+	AspectPenalty   float64  `short:"a" long:"aspect-penalty" description:"Aspect penalty for non-square textures" default:"0.25" yaml:"aspect_penalty"`
+	PreferHeight    bool     `short:"p" long:"prefer-height" description:"Prefer height over width for aspect ratio" yaml:"prefer_height"`
+	ForceSquare     bool     `short:"S" long:"force-square" description:"Force square texture" yaml:"force_square"`
+	AllowRotate     bool     `short:"R" long:"rotate" description:"Allow 90-degree rotation for better packing" yaml:"rotate"`
+	FreezeFrom      string   `long:"freeze-from" description:"Keep sprites at their coordinates in this imageset (all inputs found there unless --freeze names some)" yaml:"freeze_from"`
+	Freeze          []string `long:"freeze" description:"Keep these sprites at their current coordinates (comma separated, repeatable)" yaml:"freeze"`
+	GroupOverrides  []string `long:"group-override" description:"Per-group gap, rotation and extrusion as group:gap=N,rotate=false,extrude=N (repeatable)" yaml:"group_overrides"`
+	Explain         bool     `long:"explain" description:"Print every candidate atlas size with the reason it was rejected or chosen" yaml:"explain"`
+	NoFormatProfile bool     `long:"no-format-profile" description:"Do not apply output format defaults (dxt1/dxt5: quality 8, block align 4)" yaml:"no_format_profile"`
 }
 
 // PackInputFlags defines input discovery and preprocessing options.
 type PackInputFlags struct {
 	GroupSeparator string   `short:"s" long:"group-separator" description:"Separator for group name in filename (e.g. '_' for 'Group_Image.png')" yaml:"group_separator"`
-	AlphaKey       string   `long:"alpha-key" description:"Color key as RRGGBB (e.g. ff00ff) -> alpha=0 for bmp/tga/tiff by default" default:"ff00ff" yaml:"alpha_key"`
+	AlphaKey       string   `long:"alpha-key" description:"Color key as RRGGBB (e.g. ff00ff) -> alpha=0 for bmp/tga/tiff by default; auto=detect the border color per file" default:"ff00ff" yaml:"alpha_key"`
+	AlphaKeyMode   string   `long:"alpha-key-mode" description:"Color key handling: transparent=alpha 0, replace=paint --alpha-key-color, neighbors=paint nearby sprite colors" choice:"transparent" choice:"replace" choice:"neighbors" default:"transparent" yaml:"alpha_key_mode"`
+	AlphaKeyColor  string   `long:"alpha-key-color" description:"Replacement color as RRGGBB for --alpha-key-mode replace (e.g. 1e1e1e)" yaml:"alpha_key_color"`
+	RenameMap      string   `long:"rename-map" description:"YAML map of old: new sprite names; old names stay in the imageset as aliases of renamed sprites" yaml:"rename_map"`
 	InFormats      []string `short:"i" long:"in-format" description:"Allowed input formats: png,tga,tiff,bmp (repeatable). Default: png,tga,tiff,bmp" yaml:"in_format"`
 	MaxInputSide   int      `short:"D" long:"max-input-side" description:"Downscale inputs so the longest side is at most N pixels (0=off)" default:"0" yaml:"max_input_side"`
 	GroupDirs      bool     `short:"d" long:"group-dirs" description:"Treat subdirectories as groups" yaml:"group_dirs"`
 	AlphaKeyOff    bool     `long:"alpha-key-off" description:"Disable color key transparency processing" yaml:"alpha_key_off"`
 	AlphaKeyAll    bool     `long:"alpha-key-all" description:"Apply color key to all formats, including png" yaml:"alpha_key_all"`
+	TileOversized  bool     `long:"tile-oversized" description:"Split images larger than --max-size into tiles grouped by source, with <name>.tiles.json for reassembly" yaml:"tile_oversized"`
+}
+
+// PackIOFlags defines file IO behavior.
+type PackIOFlags struct {
+	RetryDelay time.Duration `long:"io-retry-delay" description:"Delay before the first IO retry, doubled after each attempt" default:"250ms" yaml:"retry_delay"`
+	Retries    int           `long:"io-retries" description:"Retry transient read/write failures N times (e.g. on network drives)" default:"0" yaml:"retries"`
+	Verify     bool          `long:"verify-output" description:"Re-read the written EDDS and compare its base mip with the atlas before committing outputs" yaml:"verify_output"`
 }
 
 // CmdPack packs images into a texture atlas and imageset definition.
@@ -50,41 +79,140 @@ type CmdPack struct {
 	Camel bool   `short:"c" long:"camel-case" description:"Use CamelCase names in imageset output (default: snake_case)" yaml:"camel_case"`
 	Path  string `short:"P" long:"edds-path" description:"Prefix path for imageset texture reference (e.g. mod/data/images)" yaml:"edds_path"`
 	Skip  bool   `short:"u" long:"skip-unchanged" description:"Skip writing when inputs are unchanged" yaml:"skip_unchanged"`
+	Cache string `long:"cache-dir" description:"Directory for .imagehash cache files (default: output directory)" yaml:"cache_dir"`
+
+	CacheMode string `long:"cache-mode" description:"Input change detection for --skip-unchanged: content=hash every file, stat=hash only files whose size or mtime changed" choice:"content" choice:"stat" default:"content" yaml:"cache_mode"`
+
+	LayoutOnly bool `long:"layout-only" description:"Write the imageset and <name>.layout.json only, without compositing and EDDS encoding" yaml:"layout_only"`
+	LayoutJSON bool `long:"layout-json" description:"Also write <name>.layout.json with placements, rotation, gap and extrusion of every sprite" yaml:"layout_json"`
+
+	VersionSuffix string `long:"version-suffix" description:"Append _<suffix> to output names and the texture path ('git' = git describe of the input directory)" yaml:"version_suffix"`
+
+	Namespace        string `long:"namespace" description:"Prefix the imageset name with <namespace>_ to avoid collisions with other mods" yaml:"namespace"`
+	VanillaNames     string `long:"vanilla-names" description:"File with imageset names (one per line) to warn about when the generated name collides with one" yaml:"vanilla_names"`
+	NamespaceSprites bool   `long:"namespace-sprites" description:"Also prefix every sprite name with <namespace>_" yaml:"namespace_sprites"`
+
+	LockWait time.Duration `long:"lock-wait" description:"Wait up to this long while another process writes the same outputs (0 = fail immediately)" default:"0s" yaml:"lock_wait"`
+
+	WarningsJSON string `long:"warnings-json" description:"Also write warnings as JSON records with categories to this file" yaml:"-"`
 
 	Packing PackPackingFlags `group:"Packing" yaml:"packing"`
 	Input   PackInputFlags   `group:"Input" yaml:"input"`
+	IO      PackIOFlags      `group:"IO" yaml:"io"`
 
 	Args struct {
 		Input  string `positional-arg-name:"input" description:"Input directory with images" required:"yes" yaml:"input_dir"`
 		Output string `positional-arg-name:"output" description:"Output directory (default: input directory)" yaml:"output_dir"`
 	} `positional-args:"yes" required:"yes" yaml:"args"`
+
+	// warnings collects warnings of the run; nil only prints them.
+	warnings *warningLog
 }
 
 // imageFile represents a single image file.
 type imageFile struct {
 	image     image.Image
+	info      imageio.SourceInfo
 	path      string
 	name      string
 	groupName string
-	width     int
-	height    int
+	// size and modTime are the stat data from discovery.
+	modTime time.Time
+	size    int64
+	width   int
+	height  int
 }
 
 // Execute runs the pack command.
 func (c *CmdPack) Execute(args []string) error {
-	return runPack(c)
+	return c.ExecuteContext(context.Background(), args)
 }
 
-// runPack runs the pack command.
-func runPack(opts *CmdPack) error {
+// ExecuteContext runs the pack command until ctx is canceled.
+func (c *CmdPack) ExecuteContext(ctx context.Context, args []string) error {
+	if err := checkWritable(ctx, c.WarningsJSON); err != nil {
+		return err
+	}
+
+	c.warnings = &warningLog{}
+	err := runPack(ctx, c)
+	if c.WarningsJSON != "" {
+		if writeErr := c.warnings.WriteJSON(c.WarningsJSON); writeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write warnings: %w", writeErr)
+		}
+	}
+
+	return err
+}
+
+// packOutputs holds resolved output locations of a pack project.
+type packOutputs struct {
+	Dir      string
+	Name     string
+	Imageset string
+	EDDS     string
+	// Tiles is the --tile-oversized reassembly metadata, written only
+	// when an input was split.
+	Tiles string
+	// Layout is the --layout-only placement metadata.
+	Layout string
+	// Atlas is the output paired with the imageset: EDDS, or Layout
+	// with --layout-only.
+	Atlas string
+}
+
+// resolvePackOutputs resolves the imageset name and output file paths.
+func resolvePackOutputs(opts *CmdPack) (packOutputs, error) {
 	outputDir := opts.Args.Output
 	if outputDir == "" {
 		outputDir = opts.Args.Input
 	}
 
+	name := opts.Name
+	if name == "" {
+		absInput, err := filepath.Abs(opts.Args.Input)
+		if err != nil {
+			return packOutputs{}, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		name = filepath.Base(absInput)
+	}
+	if opts.VersionSuffix != "" {
+		suffix, err := resolveVersionSuffix(opts.VersionSuffix, opts.Args.Input)
+		if err != nil {
+			return packOutputs{}, err
+		}
+		if suffix != "" {
+			name += "_" + suffix
+		}
+	}
+
+	outputs := packOutputs{
+		Dir:      outputDir,
+		Name:     name,
+		Imageset: filepath.Join(outputDir, name+".imageset"),
+		EDDS:     filepath.Join(outputDir, name+".edds"),
+		Tiles:    filepath.Join(outputDir, name+".tiles.json"),
+		Layout:   filepath.Join(outputDir, name+".layout.json"),
+	}
+	outputs.Atlas = outputs.EDDS
+	if opts.LayoutOnly {
+		outputs.Atlas = outputs.Layout
+	}
+
+	return outputs, nil
+}
+
+// runPack runs the pack command.
+func runPack(ctx context.Context, opts *CmdPack) error {
 	if opts.Packing.Mipmaps < 0 {
 		return fmt.Errorf("mipmaps must be >= 0")
 	}
+	if opts.Packing.MinMipSize < 0 {
+		return fmt.Errorf("min-mip-size must be >= 0")
+	}
+	if opts.Packing.MipCap < edds.NoMipCap {
+		return fmt.Errorf("mip-cap must be >= %d", edds.NoMipCap)
+	}
 	if err := imageio.ValidateQualityLevel(opts.Packing.Quality); err != nil {
 		return fmt.Errorf("invalid --quality: %w", err)
 	}
@@ -92,183 +220,207 @@ func runPack(opts *CmdPack) error {
 	if err != nil {
 		return fmt.Errorf("invalid --output-format: %w", err)
 	}
-
-	name := opts.Name
-	if name == "" {
-		absInput, err := filepath.Abs(opts.Args.Input)
-		if err != nil {
-			return fmt.Errorf("failed to get absolute path: %w", err)
-		}
-		name = filepath.Base(absInput)
+	compression, err := imageio.ParseCompression(opts.Packing.Compression)
+	if err != nil {
+		return fmt.Errorf("invalid --compression: %w", err)
+	}
+	if opts.Packing.BlockAlign < 0 {
+		return fmt.Errorf("block-align must be >= 0")
+	}
+	if opts.Packing.Extrude < 0 {
+		return fmt.Errorf("extrude must be >= 0")
+	}
+	if opts.Packing.MipSafe < 0 {
+		return fmt.Errorf("mip-safe must be >= 0")
 	}
+	groupOverrides, err := parseGroupOverrides(opts.Packing.GroupOverrides)
+	if err != nil {
+		return err
+	}
+	if err := checkFreezeRotation(&opts.Packing, groupOverrides); err != nil {
+		return err
+	}
+	if err := validateTexturePath(opts.Path); err != nil {
+		return err
+	}
+	profile := resolvePackProfile(&opts.Packing)
 
-	imagesetPath := filepath.Join(outputDir, name+".imageset")
-	eddsPath := filepath.Join(outputDir, name+".edds")
+	outputs, err := resolvePackOutputs(opts)
+	if err != nil {
+		return err
+	}
+	outputDir := outputs.Dir
+	name := outputs.Name
+	imagesetPath := outputs.Imageset
+	eddsPath := outputs.EDDS
+	atlasPath := outputs.Atlas
+	if err := checkWritable(ctx, outputDir); err != nil {
+		return err
+	}
 
-	allowed := normalizeFormats(opts.Input.InFormats)
-	if len(allowed) == 0 {
-		allowed = map[string]bool{"png": true, "tga": true, "tiff": true, "bmp": true}
+	alphaKey, err := parseColorKey(opts.Input.AlphaKey)
+	if err != nil {
+		return err
+	}
+	alphaKeyOpts, err := parseColorKeyOptions(opts.Input.AlphaKeyMode, opts.Input.AlphaKeyColor)
+	if err != nil {
+		return err
+	}
+	if err := validateNamespace(opts.Namespace); err != nil {
+		return err
+	}
+	if opts.NamespaceSprites && opts.Namespace == "" {
+		return fmt.Errorf("--namespace-sprites requires --namespace")
+	}
+	var vanilla []string
+	if opts.VanillaNames != "" {
+		if vanilla, err = readNameList(opts.VanillaNames); err != nil {
+			return err
+		}
+	}
+	var renames []spriteRename
+	if opts.Input.RenameMap != "" {
+		if renames, err = readRenameMap(opts.Input.RenameMap); err != nil {
+			return err
+		}
 	}
 
-	alphaKeyRGB, err := imageio.ParseHexRGB(opts.Input.AlphaKey)
+	imageFiles, err := discoverImageFiles(opts)
+	if err != nil {
+		return err
+	}
+	skipped, err := skippedImageFiles(opts)
 	if err != nil {
-		return fmt.Errorf("invalid --alpha-key: %w", err)
+		return err
+	}
+	for _, path := range skipped {
+		opts.warn(warnSkippedFile, path, "skipped %s: format not in --in-format", path)
 	}
 
-	var imageFiles []imageFile
+	lock, err := acquireOutputLock(ctx, outputDir, name, opts.LockWait)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
 
-	// Read input dir
-	if opts.Input.GroupDirs {
-		groups, err := readImageFilesFromDirs(opts.Args.Input, allowed)
+	var cache *cacheRecord
+	var cachePath string
+	if opts.Skip {
+		cachePath, err = resolveCachePath(opts.Cache, outputDir, name)
 		if err != nil {
-			return fmt.Errorf("failed to read directories: %w", err)
+			return err
 		}
-
-		// stable iteration
-		groupNames := make([]string, 0, len(groups))
-		for g := range groups {
-			groupNames = append(groupNames, g)
+		if err := checkWritable(ctx, cachePath); err != nil {
+			return err
 		}
-		sort.Strings(groupNames)
-
-		for _, groupName := range groupNames {
-			for _, file := range groups[groupName] {
-				img, err := imageio.Read(file)
-				if err != nil {
-					return fmt.Errorf("failed to read image %q: %w", file, err)
-				}
-
-				img = applyColorKeyIfNeeded(img, file, opts, alphaKeyRGB)
-				img, w, h := downscaleIfNeeded(img, opts.Input.MaxInputSide)
-
-				baseName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-				imageFiles = append(imageFiles, imageFile{
-					path:      file,
-					name:      baseName,
-					groupName: groupName,
-					width:     w,
-					height:    h,
-					image:     img,
-				})
-			}
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0750); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
 		}
 
-		// root (no group)
-		rootFiles, err := readImageFiles(opts.Args.Input, allowed)
+		inputsHash, inputs, err := computeInputsHash(ctx, opts, imageFiles, knownInputHashes(opts, cachePath))
 		if err != nil {
-			return fmt.Errorf("failed to read root directory: %w", err)
-		}
-
-		for _, file := range rootFiles {
-			img, err := imageio.Read(file)
-			if err != nil {
-				return fmt.Errorf("failed to read image %q: %w", file, err)
-			}
-
-			img = applyColorKeyIfNeeded(img, file, opts, alphaKeyRGB)
-			img, w, h := downscaleIfNeeded(img, opts.Input.MaxInputSide)
-
-			baseName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-			imageFiles = append(imageFiles, imageFile{
-				path:      file,
-				name:      baseName,
-				groupName: "",
-				width:     w,
-				height:    h,
-				image:     img,
-			})
+			return err
 		}
-	} else if opts.Input.GroupSeparator != "" {
-		files, err := readImageFiles(opts.Args.Input, allowed)
+		settingsHash, err := computeSettingsHash(opts)
 		if err != nil {
-			return fmt.Errorf("failed to read input directory: %w", err)
+			return err
 		}
 
-		for _, file := range files {
-			img, err := imageio.Read(file)
-			if err != nil {
-				return fmt.Errorf("failed to read image %q: %w", file, err)
+		cache = newCacheRecord(inputsHash, settingsHash, inputs)
+		if shouldSkipPack(cachePath, cache, outputDir, imagesetPath, atlasPath) {
+			if opts.CacheMode == cacheModeStat {
+				// Best effort: a failed refresh only costs a rehash next time.
+				_ = refreshCacheInputs(cachePath, inputs)
 			}
+			fmt.Printf("Inputs unchanged; skipping write for %s\n", imagesetPath)
+			return nil
+		}
+	}
 
-			img = applyColorKeyIfNeeded(img, file, opts, alphaKeyRGB)
-			img, w, h := downscaleIfNeeded(img, opts.Input.MaxInputSide)
-
-			baseName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-			groupName, imageName := splitGroupName(baseName, opts.Input.GroupSeparator)
-
-			imageFiles = append(imageFiles, imageFile{
-				path:      file,
-				name:      imageName,
-				groupName: groupName,
-				width:     w,
-				height:    h,
-				image:     img,
-			})
+	if !opts.Force {
+		if _, err := os.Stat(imagesetPath); err == nil {
+			return fmt.Errorf("output file %q already exists (use --force)", imagesetPath)
 		}
-	} else {
-		files, err := readImageFiles(opts.Args.Input, allowed)
-		if err != nil {
-			return fmt.Errorf("failed to read input directory: %w", err)
+		if _, err := os.Stat(atlasPath); err == nil {
+			return fmt.Errorf("output file %q already exists (use --force)", atlasPath)
 		}
+	}
 
-		for _, file := range files {
-			img, err := imageio.Read(file)
-			if err != nil {
-				return fmt.Errorf("failed to read image %q: %w", file, err)
-			}
-
-			img = applyColorKeyIfNeeded(img, file, opts, alphaKeyRGB)
-			img, w, h := downscaleIfNeeded(img, opts.Input.MaxInputSide)
-
-			baseName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-			imageFiles = append(imageFiles, imageFile{
-				path:      file,
-				name:      baseName,
-				groupName: "",
-				width:     w,
-				height:    h,
-				image:     img,
-			})
-		}
+	if err := loadImageFiles(ctx, opts, imageFiles, alphaKey, alphaKeyOpts); err != nil {
+		return err
+	}
+	for _, finding := range lintSources(imageFiles) {
+		opts.warn(warnMixedSources, "", "%s", finding)
 	}
 
-	if len(imageFiles) == 0 {
-		return fmt.Errorf("no input images found in %q", opts.Args.Input)
+	cfg := atlasforge.Options{
+		MinSize:       opts.Packing.MinSize,
+		MaxSize:       opts.Packing.MaxSize,
+		Padding:       opts.Packing.Gap,
+		PreferHeight:  opts.Packing.PreferHeight,
+		ForceSquare:   opts.Packing.ForceSquare,
+		AllowRotate:   opts.Packing.AllowRotate,
+		AspectPenalty: opts.Packing.AspectPenalty,
+		Heuristic:     parseRule(opts.Packing.Rule),
 	}
 
-	// detect name collisions (global)
-	seen := make(map[string]string, len(imageFiles))
-	for _, f := range imageFiles {
-		key := f.name
-		if prev, ok := seen[key]; ok {
-			return fmt.Errorf("duplicate image name %q (paths: %q and %q). rename or enable grouping separator/dirs", key, prev, f.path)
+	if report := profile.String(); report != "" {
+		opts.warn(warnAutoAdjusted, "", "format profile %s: %s", opts.Packing.OutputFormat, report)
+	}
+	if opts.Packing.MipSafe > 0 {
+		gap, level := mipSafeGap(opts.Packing.OutputFormat, opts.Packing.MipSafe, opts.Packing.Mipmaps, opts.Packing.MipCap)
+		if level < opts.Packing.MipSafe {
+			opts.warn(warnAutoAdjusted, "", "mip-safe level %d clamped to %d, the last written mip", opts.Packing.MipSafe, level)
+		}
+		if gap > cfg.Padding {
+			opts.warn(warnAutoAdjusted, "", "gap raised from %d to %d for mip-safe level %d", cfg.Padding, gap, level)
+			cfg.Padding = gap
+		}
+	}
+	if gap, notes := recommendGap(opts.Packing.OutputFormat, cfg.Padding, profile.blockAlign, opts.Packing.Mipmaps); len(notes) > 0 {
+		if opts.Packing.AutoGap {
+			opts.warn(warnAutoAdjusted, "", "gap raised from %d to %d: %s", cfg.Padding, gap, strings.Join(notes, "; "))
+			cfg.Padding = gap
+		} else {
+			opts.warn(warnGapBleeding, "", "%s; use --gap %d or --auto-gap", strings.Join(notes, "; "), gap)
 		}
-		seen[key] = f.path
 	}
 
-	cachePath := filepath.Join(outputDir, name+".imagehash")
-	var inputsHash uint64
-	if opts.Skip {
-		var err error
-		inputsHash, err = computeInputsHash(opts, imageFiles)
+	var tiled []tiledSource
+	if opts.Input.TileOversized {
+		imageFiles, tiled, err = tileOversized(imageFiles, tileSide(cfg.MaxSize, cfg.Padding), opts.Camel)
 		if err != nil {
 			return err
 		}
-		if shouldSkipPack(cachePath, imagesetPath, eddsPath, inputsHash) {
-			fmt.Printf("Inputs unchanged; skipping write for %s\n", imagesetPath)
-			return nil
+		for _, src := range tiled {
+			fmt.Printf("Tiled %s (%dx%d) into %d tiles\n", src.Name, src.Width, src.Height, len(src.Tiles))
 		}
 	}
 
-	if !opts.Force {
-		if _, err := os.Stat(imagesetPath); err == nil {
-			return fmt.Errorf("output file %q already exists (use --force)", imagesetPath)
-		}
-		if _, err := os.Stat(eddsPath); err == nil {
-			return fmt.Errorf("output file %q already exists (use --force)", eddsPath)
+	for _, g := range recommendGroupGaps(imageFiles, groupOverrides, opts.Packing.OutputFormat, profile.blockAlign, opts.Packing.Mipmaps) {
+		if opts.Packing.AutoGap {
+			opts.warn(warnAutoAdjusted, "", "gap of group %q raised from %d to %d: %s", g.group, g.gap, g.want, strings.Join(g.notes, "; "))
+			o := groupOverrides[g.group]
+			o.gap = &g.want
+			groupOverrides[g.group] = o
+		} else {
+			opts.warn(warnGapBleeding, "", "group %q: %s; use --group-override %s:gap=%d or --auto-gap", g.group, strings.Join(g.notes, "; "), g.group, g.want)
 		}
 	}
 
+	spriteItems, extrude, unused, err := resolveSpriteOptions(imageFiles, groupOverrides, cfg, opts.Packing.Extrude)
+	if err != nil {
+		return err
+	}
+	for _, group := range unused {
+		opts.warn(warnGroupOverride, "", "--group-override for %q matches no input group", group)
+	}
+
+	frozen, err := resolveFrozen(&opts.Packing, imagesetPath, imageFiles, opts.spriteNamespace())
+	if err != nil {
+		return err
+	}
+
 	sprites := make([]atlasforge.Sprite, 0, len(imageFiles))
 	for _, imgFile := range imageFiles {
 		sprites = append(sprites, atlasforge.Sprite{
@@ -279,33 +431,71 @@ func runPack(opts *CmdPack) error {
 		})
 	}
 
-	cfg := atlasforge.Options{
-		MinSize:       opts.Packing.MinSize,
-		MaxSize:       opts.Packing.MaxSize,
-		Padding:       opts.Packing.Gap,
-		PreferHeight:  opts.Packing.PreferHeight,
-		ForceSquare:   opts.Packing.ForceSquare,
-		AllowRotate:   opts.Packing.AllowRotate,
-		AspectPenalty: opts.Packing.AspectPenalty,
-		Heuristic:     parseRule(opts.Packing.Rule),
+	layout := layoutOptions{
+		frozen:     frozen.placements,
+		minWidth:   frozen.width,
+		minHeight:  frozen.height,
+		blockAlign: profile.blockAlign,
+		items:      spriteItems,
+		extrude:    extrude,
 	}
+	if opts.Packing.Explain {
+		if err := printExplain(sprites, cfg, layout); err != nil {
+			return fmt.Errorf("failed to explain atlas size: %w", err)
+		}
+	}
+
+	var result *atlasforge.Atlas
+	err = runCancelable(ctx, func() error {
+		if opts.LayoutOnly {
+			planned, planErr := planAtlas(sprites, cfg, layout)
+			if planErr != nil {
+				return planErr
+			}
+			result = &atlasforge.Atlas{Layout: *planned}
+			return nil
+		}
 
-	result, err := atlasforge.Pack(sprites, cfg)
+		var packErr error
+		result, packErr = packAtlas(sprites, cfg, layout)
+		return packErr
+	})
 	if err != nil {
+		if errors.Is(err, ErrInterrupted) {
+			return err
+		}
+		var placement *packer.PlacementError
+		if errors.As(err, &placement) {
+			paths, dumpErr := writePlacementDump(outputDir, name, sprites, placement)
+			if len(paths) > 0 {
+				fmt.Fprintf(os.Stderr, "Placement dump: %s\n", strings.Join(paths, ", "))
+			}
+			if dumpErr != nil {
+				opts.warn(warnPlacementDump, "", "placement dump failed: %v", dumpErr)
+			}
+			return fmt.Errorf("failed to pack images: %w (%s)", err, placementHint(placement))
+		}
 		return fmt.Errorf("failed to pack images: %w", err)
 	}
+	warnFrozenGrowth(opts, frozen, &result.Layout)
 
 	if err := os.MkdirAll(outputDir, 0750); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	workDir, err := newProjectWorkDir(outputDir, name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = workDir.Close() }()
+
 	placementMap := make(map[string]atlasforge.Placement, len(result.Layout.Placements))
 	for _, placement := range result.Layout.Placements {
 		placementMap[placement.ID] = placement
 	}
 
 	imagesetData := &imageset.Document{
-		Name: name,
+		Name: namespaced(opts.Namespace, name),
 		RefSize: imageset.Size{
 			Width:  result.Layout.Width,
 			Height: result.Layout.Height,
@@ -367,29 +557,101 @@ func runPack(opts *CmdPack) error {
 	} else {
 		imagesetData.Images = rootImages
 	}
-
-	imagesetFile, err := os.Create(imagesetPath)
-	if err != nil {
-		return fmt.Errorf("failed to create imageset file: %w", err)
+	for _, note := range addRenameAliases(imagesetData, renames) {
+		opts.warn(warnRenameMap, opts.Input.RenameMap, "%s", note)
+	}
+	namespaceSprites(imagesetData, opts.spriteNamespace())
+	if v, ok := vanillaCollision(imagesetData.Name, vanilla); ok {
+		opts.warn(warnNameCollision, opts.VanillaNames, "imageset name %q collides with vanilla imageset %q; use --namespace", imagesetData.Name, v)
 	}
-	defer func() { _ = imagesetFile.Close() }()
 
-	if err := imageset.Write(imagesetFile, imagesetData, &imageset.FormatOptions{
-		UseCamelCaseNames: opts.Camel,
+	retry := opts.retryPolicy()
+	if err := retry.Do(ctx, "write "+imagesetPath, func() error {
+		return imageset.WriteFile(workDir.Path(imagesetPath), imagesetData, &imageset.FormatOptions{
+			UseCamelCaseNames: opts.Camel,
+		})
 	}); err != nil {
 		return fmt.Errorf("failed to write imageset file: %w", err)
 	}
 
-	if err := imageio.WriteWithOptions(eddsPath, result.Image, &imageio.EncodeSettings{
-		Format:  outputFormat,
-		Quality: opts.Packing.Quality,
-		Mipmaps: opts.Packing.Mipmaps,
-	}); err != nil {
-		return fmt.Errorf("failed to write EDDS file: %w", err)
+	outputPaths := []string{imagesetPath, atlasPath}
+	if opts.LayoutOnly || opts.LayoutJSON {
+		manifest := newLayoutManifest(imagesetData.Name, imagesetData.Textures[0].Path, opts.Args.Input, cfg.Padding, layout, imageFiles, &result.Layout, opts.Camel)
+		for i := range manifest.Sprites {
+			manifest.Sprites[i].Name = imageset.NormalizeName(namespaced(opts.spriteNamespace(), manifest.Sprites[i].Name), opts.Camel)
+		}
+		if err := retry.Do(ctx, "write "+outputs.Layout, func() error {
+			return writeLayoutManifest(workDir.Path(outputs.Layout), manifest)
+		}); err != nil {
+			return fmt.Errorf("failed to write layout metadata: %w", err)
+		}
+		if !opts.LayoutOnly {
+			outputPaths = append(outputPaths, outputs.Layout)
+		}
+	}
+	if !opts.LayoutOnly {
+		err := runCancelable(ctx, func() error {
+			err := retry.Do(ctx, "write "+eddsPath, func() error {
+				return imageio.WriteWithOptions(workDir.Path(eddsPath), result.Image, &imageio.EncodeSettings{
+					Format:      outputFormat,
+					Quality:     profile.quality,
+					Mipmaps:     opts.Packing.Mipmaps,
+					MinMipSize:  opts.Packing.MinMipSize,
+					MipCap:      opts.Packing.MipCap,
+					Compression: compression,
+				})
+			})
+			if err != nil || !opts.IO.Verify {
+				return err
+			}
+			if err := imageio.VerifyEDDS(workDir.Path(eddsPath), result.Image, outputFormat); err != nil {
+				return fmt.Errorf("verify output: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, ErrInterrupted) {
+				return err
+			}
+			return fmt.Errorf("failed to write EDDS file: %w", err)
+		}
+	}
+
+	if len(tiled) > 0 {
+		if err := retry.Do(ctx, "write "+outputs.Tiles, func() error {
+			return writeTileManifest(workDir.Path(outputs.Tiles), tiled)
+		}); err != nil {
+			return fmt.Errorf("failed to write tile manifest: %w", err)
+		}
+		outputPaths = append(outputPaths, outputs.Tiles)
 	}
 
-	if opts.Skip && inputsHash != 0 {
-		if err := writeCacheHash(cachePath, inputsHash); err != nil {
+	// Commit is not retried: a failed commit may have moved some outputs
+	// out of the work directory already.
+	if err := workDir.Commit(outputPaths...); err != nil {
+		return err
+	}
+	if len(tiled) == 0 {
+		// A manifest left by an earlier tiled pack no longer matches.
+		if err := os.Remove(outputs.Tiles); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale tile manifest: %w", err)
+		}
+	}
+	if !opts.LayoutOnly && !opts.LayoutJSON {
+		// Layout metadata of an earlier layout pack is outdated.
+		if err := os.Remove(outputs.Layout); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale layout metadata: %w", err)
+		}
+	}
+
+	if cache != nil {
+		cache.Outputs, err = hashOutputs(outputPaths...)
+		if err != nil {
+			return err
+		}
+		if err := retry.Do(ctx, "write "+cachePath, func() error {
+			return writeCache(cachePath, cache)
+		}); err != nil {
 			return err
 		}
 	}
@@ -412,23 +674,208 @@ func runPack(opts *CmdPack) error {
 			result.Layout.Height,
 		)
 	}
-	fmt.Printf("Outputs: %s, %s\n", imagesetPath, eddsPath)
+	fmt.Printf("Outputs: %s\n", strings.Join(outputPaths, ", "))
+
+	return nil
+}
+
+// defaultInputFormats returns the input formats pack reads by default.
+func defaultInputFormats() map[string]bool {
+	return map[string]bool{"png": true, "tga": true, "tiff": true, "bmp": true}
+}
+
+// skippedImageFiles lists input files of a default format that --in-format
+// excludes, from the same directories discoverImageFiles reads.
+func skippedImageFiles(opts *CmdPack) ([]string, error) {
+	allowed := normalizeFormats(opts.Input.InFormats)
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+
+	excluded := defaultInputFormats()
+	for format := range allowed {
+		delete(excluded, format)
+	}
+	if len(excluded) == 0 {
+		return nil, nil
+	}
+
+	files, err := readImageFiles(opts.Args.Input, excluded)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Input.GroupDirs {
+		groups, err := readImageFilesFromDirs(opts.Args.Input, excluded)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			files = append(files, group...)
+		}
+	}
+
+	skipped := make([]string, 0, len(files))
+	for _, file := range files {
+		skipped = append(skipped, file.path)
+	}
+	sort.Strings(skipped)
+
+	return skipped, nil
+}
+
+// discoverImageFiles lists input images with their imageset names and groups
+// without decoding pixel data. Duplicate names are reported as errors.
+func discoverImageFiles(opts *CmdPack) ([]imageFile, error) {
+	allowed := normalizeFormats(opts.Input.InFormats)
+	if len(allowed) == 0 {
+		allowed = defaultInputFormats()
+	}
+
+	var imageFiles []imageFile
+
+	// Read input dir
+	if opts.Input.GroupDirs {
+		groups, err := readImageFilesFromDirs(opts.Args.Input, allowed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directories: %w", err)
+		}
+
+		// stable iteration
+		groupNames := make([]string, 0, len(groups))
+		for g := range groups {
+			groupNames = append(groupNames, g)
+		}
+		sort.Strings(groupNames)
+
+		for _, groupName := range groupNames {
+			for _, file := range groups[groupName] {
+				imageFiles = append(imageFiles, imageFile{
+					path:      file.path,
+					name:      strings.TrimSuffix(filepath.Base(file.path), filepath.Ext(file.path)),
+					groupName: groupName,
+					size:      file.size,
+					modTime:   file.modTime,
+				})
+			}
+		}
+
+		// root (no group)
+		rootFiles, err := readImageFiles(opts.Args.Input, allowed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root directory: %w", err)
+		}
+
+		for _, file := range rootFiles {
+			imageFiles = append(imageFiles, imageFile{
+				path:    file.path,
+				name:    strings.TrimSuffix(filepath.Base(file.path), filepath.Ext(file.path)),
+				size:    file.size,
+				modTime: file.modTime,
+			})
+		}
+	} else {
+		files, err := readImageFiles(opts.Args.Input, allowed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input directory: %w", err)
+		}
+
+		for _, file := range files {
+			baseName := strings.TrimSuffix(filepath.Base(file.path), filepath.Ext(file.path))
+			groupName, imageName := "", baseName
+			if opts.Input.GroupSeparator != "" {
+				groupName, imageName = splitGroupName(baseName, opts.Input.GroupSeparator)
+			}
+
+			imageFiles = append(imageFiles, imageFile{
+				path:      file.path,
+				name:      imageName,
+				groupName: groupName,
+				size:      file.size,
+				modTime:   file.modTime,
+			})
+		}
+	}
+
+	if len(imageFiles) == 0 {
+		return nil, fmt.Errorf("no input images found in %q", opts.Args.Input)
+	}
+
+	// detect name collisions (global)
+	seen := make(map[string]string, len(imageFiles))
+	for _, f := range imageFiles {
+		key := f.name
+		if prev, ok := seen[key]; ok {
+			return nil, fmt.Errorf("duplicate image name %q (paths: %q and %q). rename or enable grouping separator/dirs", key, prev, f.path)
+		}
+		seen[key] = f.path
+	}
+
+	return imageFiles, nil
+}
+
+// loadImageFiles decodes discovered images and applies color key and
+// downscale. A nil key is detected per file; see applyColorKeyIfNeeded.
+func loadImageFiles(ctx context.Context, opts *CmdPack, files []imageFile, key *imageio.RGB, keyOpts *imageio.ColorKeyOptions) error {
+	retry := opts.retryPolicy()
+	detected := make(map[imageio.RGB]int)
+	for i := range files {
+		if err := checkInterrupted(ctx); err != nil {
+			return err
+		}
+
+		f := &files[i]
+
+		var img image.Image
+		err := retry.Do(ctx, "read "+f.path, func() error {
+			var readErr error
+			img, readErr = imageio.Read(f.path)
+			if readErr != nil {
+				return readErr
+			}
+			f.info, readErr = imageio.Inspect(f.path, img)
+			return readErr
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read image %q: %w", f.path, err)
+		}
+
+		var applied *imageio.RGB
+		img, applied = applyColorKeyIfNeeded(img, f.path, opts, key, keyOpts)
+		if key == nil && applied != nil {
+			detected[*applied]++
+		}
+		f.image, f.width, f.height = downscaleIfNeeded(img, opts.Input.MaxInputSide)
+	}
+
+	if key == nil && !opts.Input.AlphaKeyOff {
+		fmt.Printf("Auto alpha key: %s\n", describeDetectedKeys(detected))
+	}
 
 	return nil
 }
 
-// applyColorKeyIfNeeded applies the color key if needed.
-func applyColorKeyIfNeeded(img image.Image, path string, opts *CmdPack, key imageio.RGB) image.Image {
+// applyColorKeyIfNeeded applies the color key to bmp/tga/tiff inputs, or
+// to all with --alpha-key-all, and returns the applied key. A nil key is
+// detected from the image border and images without a flat background
+// are left as they are.
+func applyColorKeyIfNeeded(img image.Image, path string, opts *CmdPack, key *imageio.RGB, keyOpts *imageio.ColorKeyOptions) (image.Image, *imageio.RGB) {
 	if opts.Input.AlphaKeyOff {
-		return img
+		return img, nil
 	}
 
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
-	if opts.Input.AlphaKeyAll || ext == "bmp" || ext == "tga" || ext == "tiff" {
-		return imageio.ApplyColorKey(img, key)
+	if !opts.Input.AlphaKeyAll && ext != "bmp" && ext != "tga" && ext != "tiff" {
+		return img, nil
+	}
+	if key == nil {
+		detected, ok := imageio.DetectColorKey(img)
+		if !ok {
+			return img, nil
+		}
+		key = &detected
 	}
 
-	return img
+	return imageio.ApplyColorKeyWithOptions(img, *key, keyOpts), key
 }
 
 // downscaleIfNeeded downscales the image if needed.
@@ -501,37 +948,60 @@ func normalizeFormats(in []string) map[string]bool {
 	return m
 }
 
+// discoveredFile is an input file found by directory listing, with the
+// size from the listing so hashing does not stat it again.
+type discoveredFile struct {
+	modTime time.Time
+	path    string
+	size    int64
+}
+
 // readImageFiles reads the image files from the directory.
-func readImageFiles(dir string, allowed map[string]bool) ([]string, error) {
+func readImageFiles(dir string, allowed map[string]bool) ([]discoveredFile, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	var out []string
+	var out []discoveredFile
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
 		}
 
 		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(e.Name()), "."))
-		if allowed[ext] {
-			out = append(out, filepath.Join(dir, e.Name()))
+		if !allowed[ext] {
+			continue
 		}
+
+		path := filepath.Join(dir, e.Name())
+		var info fs.FileInfo
+		if e.Type()&fs.ModeSymlink != 0 {
+			info, err = os.Stat(path)
+		} else {
+			info, err = e.Info()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, discoveredFile{path: path, size: info.Size(), modTime: info.ModTime()})
 	}
 
-	sort.Strings(out)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].path < out[j].path
+	})
 	return out, nil
 }
 
 // readImageFilesFromDirs reads the image files from the directories.
-func readImageFilesFromDirs(rootDir string, allowed map[string]bool) (map[string][]string, error) {
+func readImageFilesFromDirs(rootDir string, allowed map[string]bool) (map[string][]discoveredFile, error) {
 	entries, err := os.ReadDir(rootDir)
 	if err != nil {
 		return nil, err
 	}
 
-	groups := make(map[string][]string)
+	groups := make(map[string][]discoveredFile)
 	for _, e := range entries {
 		if !e.IsDir() {
 			continue
@@ -583,13 +1053,7 @@ func parseRule(s string) atlasforge.Heuristic {
 
 // formatEddsRefPath formats the EDDS reference path.
 func formatEddsRefPath(prefix, name string) string {
-	p := strings.TrimSpace(prefix)
-	if p == "" {
-		return fmt.Sprintf("%s.edds", name)
-	}
-
-	p = strings.ReplaceAll(p, "\\", "/")
-	p = strings.Trim(p, "/")
+	p := cleanTexturePath(prefix)
 	if p == "" {
 		return fmt.Sprintf("%s.edds", name)
 	}
@@ -1,149 +1,611 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
-	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
+	"github.com/woozymasta/imageset-packer/internal/vars"
+	"gopkg.in/yaml.v3"
 )
 
-// cacheEntry is a cache entry.
-type cacheEntry struct {
+const (
+	// cacheMagic marks versioned .imagehash files.
+	cacheMagic = "ISPH"
+	// cacheVersion is the current .imagehash layout version.
+	cacheVersion uint16 = 2
+	// legacyCacheSize is the size of pre-versioned .imagehash files (bare xxhash).
+	legacyCacheSize = 8
+	// minHashWorkers is the smallest number of concurrent input hashers.
+	minHashWorkers = 8
+	// racyModTime is how recent a file change may be for its mtime to be
+	// recorded; newer files could change again within the same mtime tick.
+	racyModTime = 2 * time.Second
+)
+
+// Input change detection modes of --cache-mode.
+const (
+	cacheModeContent = "content"
+	cacheModeStat    = "stat"
+)
+
+// errCacheCorrupt reports an .imagehash file that fails structure or CRC checks.
+var errCacheCorrupt = errors.New("cache file is corrupt")
+
+// cacheFile is one input file recorded in the cache.
+type cacheFile struct {
+	// Path is relative to the input directory, with forward slashes.
 	Path string
-	Hash string
-	Size int64
+	// Size and ModTime (Unix nanoseconds) are the stat data the hash was
+	// taken with; ModTime 0 forces a rehash with --cache-mode stat.
+	Size    int64
+	ModTime int64
+	Hash    uint64
 }
 
-// computeInputsHash computes the hash of the input files.
-func computeInputsHash(opts *CmdPack, files []imageFile) (uint64, error) {
+// cacheRecord is the decoded content of an .imagehash file.
+type cacheRecord struct {
+	// ToolVersion is the imageset-packer version that wrote the outputs.
+	ToolVersion string
+	// Outputs lists written files (base names) with their content hashes.
+	Outputs []cacheOutput
+	// Files lists input files with stat data and content hashes.
+	Files []cacheFile
+	// SettingsHash covers all options that affect output content.
+	SettingsHash uint64
+	// InputsHash covers input file paths, contents and sizes.
+	InputsHash uint64
+	// Legacy is set for bare 8-byte caches that only carry InputsHash.
+	Legacy bool
+}
+
+// cacheOutput is one output file recorded in the cache.
+type cacheOutput struct {
+	Name string
+	Hash uint64
+}
+
+// resolveCachePath returns the .imagehash path for an output set.
+// Without cacheDir the cache lives next to the outputs. A shared cacheDir
+// keys files by name and output directory so projects with equal names
+// in different output directories do not collide.
+func resolveCachePath(cacheDir, outputDir, name string) (string, error) {
+	if strings.TrimSpace(cacheDir) == "" {
+		return filepath.Join(outputDir, name+".imagehash"), nil
+	}
+
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", fmt.Errorf("resolve output path: %w", err)
+	}
+	key := xxhash.Sum64String(filepath.ToSlash(absOutput))
+	return filepath.Join(cacheDir, fmt.Sprintf("%s-%08x.imagehash", name, uint32(key))), nil //nolint:gosec // Truncated on purpose.
+}
+
+// computeInputsHash computes the hash of the input files and returns the
+// per-file records for the cache. Files whose path, size and mtime match an
+// entry of known reuse its hash instead of being read.
+func computeInputsHash(ctx context.Context, opts *CmdPack, files []imageFile, known map[string]cacheFile) (uint64, []cacheFile, error) {
 	root, err := filepath.Abs(opts.Args.Input)
 	if err != nil {
-		return 0, fmt.Errorf("resolve input path: %w", err)
+		return 0, nil, fmt.Errorf("resolve input path: %w", err)
 	}
 
-	entries := make([]cacheEntry, 0, len(files))
-	for _, f := range files {
+	entries := make([]cacheFile, len(files))
+	absPaths := make([]string, len(files))
+	var pending []int
+	for i, f := range files {
 		absPath, err := filepath.Abs(f.path)
 		if err != nil {
-			return 0, fmt.Errorf("resolve file path %q: %w", f.path, err)
+			return 0, nil, fmt.Errorf("resolve file path %q: %w", f.path, err)
 		}
 
 		rel, err := filepath.Rel(root, absPath)
 		if err != nil {
-			return 0, fmt.Errorf("resolve relative path for %q: %w", absPath, err)
+			return 0, nil, fmt.Errorf("resolve relative path for %q: %w", absPath, err)
 		}
 
-		fileHash, size, err := hashFileXX(absPath)
-		if err != nil {
-			return 0, err
+		absPaths[i] = absPath
+		entries[i] = cacheFile{Path: filepath.ToSlash(rel), Size: f.size, ModTime: f.modTime.UnixNano()}
+		if prev, ok := known[entries[i].Path]; ok && prev.ModTime != 0 && prev.ModTime == entries[i].ModTime && prev.Size == f.size {
+			entries[i].Hash = prev.Hash
+			continue
 		}
+		pending = append(pending, i)
+	}
 
-		entries = append(entries, cacheEntry{
-			Path: filepath.ToSlash(rel),
-			Hash: fileHash,
-			Size: size,
+	retry := opts.retryPolicy()
+	err = parallelFor(ctx, len(pending), hashWorkers(len(pending)), func(n int) error {
+		i := pending[n]
+		return retry.Do(ctx, "hash "+absPaths[i], func() error {
+			fileHash, err := hashFileXX(absPaths[i])
+			if err != nil {
+				return err
+			}
+			entries[i].Hash = fileHash
+			return nil
 		})
+	})
+	if err != nil {
+		return 0, nil, err
 	}
 
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Path < entries[j].Path
 	})
 
+	racy := time.Now().Add(-racyModTime).UnixNano()
 	h := xxhash.New()
-	for _, e := range entries {
-		if _, err := h.WriteString(e.Path); err != nil {
-			return 0, err
+	for i := range entries {
+		e := &entries[i]
+		if _, err := fmt.Fprintf(h, "%s\x00%016x\x00%d\n", e.Path, e.Hash, e.Size); err != nil {
+			return 0, nil, err
 		}
-		if _, err := h.Write([]byte{0}); err != nil {
-			return 0, err
-		}
-		if _, err := h.WriteString(e.Hash); err != nil {
-			return 0, err
+		if e.ModTime > racy {
+			e.ModTime = 0
 		}
-		if _, err := h.Write([]byte{0}); err != nil {
+	}
+
+	return h.Sum64(), entries, nil
+}
+
+// knownInputHashes returns input hashes recorded in the cache at cachePath
+// for --cache-mode stat, keyed by relative path. Other modes, missing and
+// unreadable caches yield nil, so every input is hashed.
+func knownInputHashes(opts *CmdPack, cachePath string) map[string]cacheFile {
+	if opts.CacheMode != cacheModeStat {
+		return nil
+	}
+
+	prev, err := readCache(cachePath)
+	if err != nil || prev == nil {
+		return nil
+	}
+
+	known := make(map[string]cacheFile, len(prev.Files))
+	for _, f := range prev.Files {
+		known[f.Path] = f
+	}
+
+	return known
+}
+
+// computeSettingsHash hashes every option that affects the generated outputs,
+// including the content of the rename map and of the --freeze-from
+// imageset. Flags that only control the run
+// itself (force, skip-unchanged, explain) are excluded.
+func computeSettingsHash(opts *CmdPack) (uint64, error) {
+	settings := struct {
+		Name      string           `yaml:"name"`
+		Namespace string           `yaml:"namespace,omitempty"`
+		Path      string           `yaml:"edds_path"`
+		Packing   PackPackingFlags `yaml:"packing"`
+		Input     PackInputFlags   `yaml:"input"`
+		Camel     bool             `yaml:"camel_case"`
+		Layout    bool             `yaml:"layout_only"`
+		Renames   []spriteRename   `yaml:"renames,omitempty"`
+		Sprites   bool             `yaml:"namespace_sprites,omitempty"`
+		JSON      bool             `yaml:"layout_json,omitempty"`
+		Frozen    uint64           `yaml:"freeze_from_hash,omitempty"`
+	}{
+		Name:      opts.Name,
+		Namespace: opts.Namespace,
+		Path:      opts.Path,
+		Packing:   opts.Packing,
+		Input:     opts.Input,
+		Camel:     opts.Camel,
+		Layout:    opts.LayoutOnly,
+		Sprites:   opts.NamespaceSprites,
+		JSON:      opts.LayoutJSON,
+	}
+	settings.Packing.Explain = false
+	if opts.Input.RenameMap != "" {
+		renames, err := readRenameMap(opts.Input.RenameMap)
+		if err != nil {
 			return 0, err
 		}
-		if _, err := h.WriteString(strconv.FormatInt(e.Size, 10)); err != nil {
+		settings.Renames = renames
+	}
+	if opts.Packing.FreezeFrom != "" {
+		hash, err := hashFileXX(opts.Packing.FreezeFrom)
+		if err != nil {
 			return 0, err
 		}
-		if _, err := h.Write([]byte{'\n'}); err != nil {
-			return 0, err
+		settings.Frozen = hash
+	}
+
+	data, err := yaml.Marshal(&settings)
+	if err != nil {
+		return 0, fmt.Errorf("encode settings: %w", err)
+	}
+
+	return xxhash.Sum64(data), nil
+}
+
+// hashOutputs hashes written output files for the cache record.
+func hashOutputs(paths ...string) ([]cacheOutput, error) {
+	outputs := make([]cacheOutput, 0, len(paths))
+	for _, path := range paths {
+		hash, err := hashFileXX(path)
+		if err != nil {
+			return nil, err
 		}
+
+		outputs = append(outputs, cacheOutput{
+			Name: filepath.Base(path),
+			Hash: hash,
+		})
 	}
 
-	return h.Sum64(), nil
+	return outputs, nil
 }
 
 // shouldSkipPack checks if the pack should be skipped.
-func shouldSkipPack(cachePath, imagesetPath, eddsPath string, nextHash uint64) bool {
-	prevHash, ok, err := readCacheHash(cachePath)
-	if err != nil || !ok {
-		return false
+func shouldSkipPack(cachePath string, next *cacheRecord, outputDir string, outputPaths ...string) bool {
+	return staleReason(cachePath, next, outputDir, outputPaths...) == ""
+}
+
+// staleReason explains why cached outputs cannot be reused; "" means up to date.
+// Outputs must exist and, for versioned caches, still match recorded hashes.
+func staleReason(cachePath string, next *cacheRecord, outputDir string, outputPaths ...string) string {
+	prev, err := readCache(cachePath)
+	if err != nil {
+		return err.Error()
 	}
-	if prevHash != nextHash {
-		return false
+	if prev == nil {
+		return "no cache"
 	}
-	if _, err := os.Stat(imagesetPath); err != nil {
-		return false
+	if prev.InputsHash != next.InputsHash {
+		return "inputs changed"
+	}
+
+	for _, path := range outputPaths {
+		if _, err := os.Stat(path); err != nil {
+			return "missing output " + filepath.Base(path)
+		}
 	}
-	if _, err := os.Stat(eddsPath); err != nil {
+
+	// Legacy caches carry only the inputs hash; keep the old behavior for them.
+	if prev.Legacy {
+		return ""
+	}
+
+	if prev.ToolVersion != next.ToolVersion {
+		return fmt.Sprintf("tool version changed (%s -> %s)", prev.ToolVersion, next.ToolVersion)
+	}
+	if prev.SettingsHash != next.SettingsHash {
+		return "settings changed"
+	}
+	if !outputsMatch(prev.Outputs, outputDir) {
+		return "outputs modified"
+	}
+
+	return ""
+}
+
+// outputsMatch reports whether recorded outputs are unchanged on disk.
+func outputsMatch(outputs []cacheOutput, outputDir string) bool {
+	if len(outputs) == 0 {
 		return false
 	}
 
+	for _, out := range outputs {
+		hash, err := hashFileXX(filepath.Join(outputDir, out.Name))
+		if err != nil || hash != out.Hash {
+			return false
+		}
+	}
+
 	return true
 }
 
-// readCacheHash reads the cache hash from the file.
-func readCacheHash(path string) (uint64, bool, error) {
+// newCacheRecord creates a cache record for the current tool version.
+func newCacheRecord(inputsHash, settingsHash uint64, files []cacheFile) *cacheRecord {
+	return &cacheRecord{
+		ToolVersion:  vars.Version,
+		SettingsHash: settingsHash,
+		InputsHash:   inputsHash,
+		Files:        files,
+	}
+}
+
+// refreshCacheInputs replaces the input records of an up-to-date versioned
+// cache, so files recorded without stat data (older caches, fresh mtimes)
+// are not hashed again by later --cache-mode stat runs.
+func refreshCacheInputs(path string, files []cacheFile) error {
+	rec, err := readCache(path)
+	if err != nil || rec == nil || rec.Legacy {
+		return err
+	}
+
+	rec.Files = files
+	return writeCache(path, rec)
+}
+
+// readCache reads the cache record from the file.
+// It returns nil without error when the file does not exist.
+func readCache(path string) (*cacheRecord, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, false, nil
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read cache: %w", err)
+	}
+
+	return decodeCache(data)
+}
+
+// writeCache writes the cache record to the file.
+func writeCache(path string, rec *cacheRecord) error {
+	data, err := encodeCache(rec)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+
+	return nil
+}
+
+// encodeCache serializes the cache record.
+//
+// Layout (little-endian):
+//
+//	magic "ISPH" | version u16 | tool version (u16 len + bytes)
+//	settings hash u64 | inputs hash u64 | output count u16
+//	outputs (u16 len + name bytes, u64 hash)... | input count u32
+//	inputs (u16 len + path bytes, i64 size, i64 mtime ns, u64 hash)...
+//	crc32 (IEEE) of all preceding bytes
+//
+// Version 1 files end after the outputs and are still read.
+func encodeCache(rec *cacheRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(cacheMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, cacheVersion)
+
+	if err := writeCacheString(&buf, rec.ToolVersion); err != nil {
+		return nil, err
+	}
+
+	_ = binary.Write(&buf, binary.LittleEndian, rec.SettingsHash)
+	_ = binary.Write(&buf, binary.LittleEndian, rec.InputsHash)
+
+	if len(rec.Outputs) > math.MaxUint16 {
+		return nil, fmt.Errorf("too many cache outputs: %d", len(rec.Outputs))
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(len(rec.Outputs))) //nolint:gosec // Bounded above.
+
+	for _, out := range rec.Outputs {
+		if err := writeCacheString(&buf, out.Name); err != nil {
+			return nil, err
 		}
+		_ = binary.Write(&buf, binary.LittleEndian, out.Hash)
+	}
 
-		return 0, false, fmt.Errorf("read cache: %w", err)
+	if len(rec.Files) > math.MaxUint32 {
+		return nil, fmt.Errorf("too many cache inputs: %d", len(rec.Files))
 	}
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(rec.Files))) //nolint:gosec // Bounded above.
 
-	if len(data) != 8 {
-		return 0, false, nil
+	for _, f := range rec.Files {
+		if err := writeCacheString(&buf, f.Path); err != nil {
+			return nil, err
+		}
+		_ = binary.Write(&buf, binary.LittleEndian, f.Size)
+		_ = binary.Write(&buf, binary.LittleEndian, f.ModTime)
+		_ = binary.Write(&buf, binary.LittleEndian, f.Hash)
 	}
 
-	return binary.LittleEndian.Uint64(data), true, nil
+	_ = binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	return buf.Bytes(), nil
 }
 
-// writeCacheHash writes the cache hash to the file.
-func writeCacheHash(path string, hash uint64) error {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, hash)
-	if err := os.WriteFile(path, buf, 0600); err != nil {
-		return fmt.Errorf("write cache: %w", err)
+// decodeCache parses versioned and legacy cache data.
+func decodeCache(data []byte) (*cacheRecord, error) {
+	if len(data) == legacyCacheSize {
+		return &cacheRecord{
+			InputsHash: binary.LittleEndian.Uint64(data),
+			Legacy:     true,
+		}, nil
+	}
+
+	if len(data) < len(cacheMagic)+2+4 || string(data[:len(cacheMagic)]) != cacheMagic {
+		return nil, fmt.Errorf("%w: unknown header", errCacheCorrupt)
+	}
+
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, fmt.Errorf("%w: crc mismatch", errCacheCorrupt)
+	}
+
+	r := bytes.NewReader(body[len(cacheMagic):])
+
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
 	}
+	if version == 0 || version > cacheVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", errCacheCorrupt, version)
+	}
+
+	rec := &cacheRecord{}
+	var err error
+	if rec.ToolVersion, err = readCacheString(r); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.SettingsHash); err != nil {
+		return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rec.InputsHash); err != nil {
+		return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+
+	var count uint16
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+
+	rec.Outputs = make([]cacheOutput, 0, count)
+	for i := 0; i < int(count); i++ {
+		name, err := readCacheString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var hash uint64
+		if err := binary.Read(r, binary.LittleEndian, &hash); err != nil {
+			return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+		}
+
+		rec.Outputs = append(rec.Outputs, cacheOutput{Name: name, Hash: hash})
+	}
+
+	if version >= 2 {
+		var files uint32
+		if err := binary.Read(r, binary.LittleEndian, &files); err != nil {
+			return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+		}
+		// Each entry takes at least 26 bytes; reject counts the data cannot hold.
+		if int64(files)*26 > int64(r.Len()) {
+			return nil, fmt.Errorf("%w: input count %d exceeds data", errCacheCorrupt, files)
+		}
+
+		rec.Files = make([]cacheFile, 0, files)
+		for range files {
+			var f cacheFile
+			if f.Path, err = readCacheString(r); err != nil {
+				return nil, err
+			}
+			for _, v := range []any{&f.Size, &f.ModTime, &f.Hash} {
+				if err := binary.Read(r, binary.LittleEndian, v); err != nil {
+					return nil, fmt.Errorf("%w: %v", errCacheCorrupt, err)
+				}
+			}
+			rec.Files = append(rec.Files, f)
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", errCacheCorrupt, r.Len())
+	}
+
+	return rec, nil
+}
+
+// writeCacheString writes a u16 length-prefixed string.
+func writeCacheString(buf *bytes.Buffer, s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("cache string too long: %d bytes", len(s))
+	}
+
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(s))) //nolint:gosec // Bounded above.
+	buf.WriteString(s)
 
 	return nil
 }
 
+// readCacheString reads a u16 length-prefixed string.
+func readCacheString(r *bytes.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+	if int(n) > r.Len() {
+		return "", fmt.Errorf("%w: string length %d exceeds data", errCacheCorrupt, n)
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("%w: %v", errCacheCorrupt, err)
+	}
+
+	return string(b), nil
+}
+
 // hashFileXX hashes the file using XXHash.
-func hashFileXX(path string) (string, int64, error) {
+func hashFileXX(path string) (uint64, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return "", 0, fmt.Errorf("open %q: %w", path, err)
+		return 0, fmt.Errorf("open %q: %w", path, err)
 	}
 	defer func() { _ = f.Close() }()
 
-	info, err := f.Stat()
-	if err != nil {
-		return "", 0, fmt.Errorf("stat %q: %w", path, err)
-	}
-
 	h := xxhash.New()
 	if _, err := io.Copy(h, f); err != nil {
-		return "", 0, fmt.Errorf("hash %q: %w", path, err)
+		return 0, fmt.Errorf("hash %q: %w", path, err)
+	}
+
+	return h.Sum64(), nil
+}
+
+// hashWorkers returns the number of concurrent file hashers for n inputs.
+// Hashing is IO bound, so at least minHashWorkers run even on small machines
+// to overlap network storage latency.
+func hashWorkers(n int) int {
+	return min(n, max(runtime.GOMAXPROCS(0), minHashWorkers))
+}
+
+// parallelFor runs fn for indexes 0..n-1 on up to workers goroutines. After
+// the first error or cancellation no new indexes start; the first error, or
+// ErrInterrupted, is returned. A panic in fn is returned as an error for the
+// crash handler.
+func parallelFor(ctx context.Context, n, workers int, fn func(i int) error) error {
+	call := func(i int) (err error) {
+		defer recoverPanic(&err)
+		return fn(i)
+	}
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for range workers {
+		wg.Go(func() {
+			for !failed.Load() && ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := call(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					failed.Store(true)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
 	}
 
-	return fmt.Sprintf("%016x", h.Sum64()), info.Size(), nil
+	return checkInterrupted(ctx)
 }
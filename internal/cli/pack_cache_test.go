@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := &cacheRecord{
+		ToolVersion:  "v1.2.3",
+		SettingsHash: 0x1122334455667788,
+		InputsHash:   0x8877665544332211,
+		Outputs: []cacheOutput{
+			{Name: "ui.imageset", Hash: 1},
+			{Name: "ui.edds", Hash: 2},
+		},
+		Files: []cacheFile{
+			{Path: "icons/a.png", Size: 120, ModTime: 1700000000123456789, Hash: 3},
+			{Path: "b.tga", Size: 7, Hash: 4},
+		},
+	}
+
+	data, err := encodeCache(want)
+	if err != nil {
+		t.Fatalf("encodeCache error: %v", err)
+	}
+
+	got, err := decodeCache(data)
+	if err != nil {
+		t.Fatalf("decodeCache error: %v", err)
+	}
+	if got.Legacy {
+		t.Fatal("decoded record marked as legacy")
+	}
+	if got.ToolVersion != want.ToolVersion || got.SettingsHash != want.SettingsHash || got.InputsHash != want.InputsHash {
+		t.Fatalf("decoded record = %+v, want %+v", got, want)
+	}
+	if len(got.Outputs) != len(want.Outputs) {
+		t.Fatalf("decoded outputs = %d, want %d", len(got.Outputs), len(want.Outputs))
+	}
+	for i := range want.Outputs {
+		if got.Outputs[i] != want.Outputs[i] {
+			t.Fatalf("output %d = %+v, want %+v", i, got.Outputs[i], want.Outputs[i])
+		}
+	}
+	if len(got.Files) != len(want.Files) {
+		t.Fatalf("decoded inputs = %d, want %d", len(got.Files), len(want.Files))
+	}
+	for i := range want.Files {
+		if got.Files[i] != want.Files[i] {
+			t.Fatalf("input %d = %+v, want %+v", i, got.Files[i], want.Files[i])
+		}
+	}
+}
+
+func TestCacheVersion1(t *testing.T) {
+	t.Parallel()
+
+	data, err := encodeCache(&cacheRecord{ToolVersion: "v0.2.0", InputsHash: 9, Outputs: []cacheOutput{{Name: "ui.edds", Hash: 5}}})
+	if err != nil {
+		t.Fatalf("encodeCache error: %v", err)
+	}
+
+	// Drop the input table and rewrite the header as version 1.
+	body := append([]byte(nil), data[:len(data)-8]...)
+	binary.LittleEndian.PutUint16(body[len(cacheMagic):], 1)
+	body = binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+
+	got, err := decodeCache(body)
+	if err != nil {
+		t.Fatalf("decodeCache(v1) error: %v", err)
+	}
+	if got.InputsHash != 9 || len(got.Outputs) != 1 || got.Files != nil {
+		t.Fatalf("decoded v1 record = %+v", got)
+	}
+}
+
+func TestCacheLegacy(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, legacyCacheSize)
+	binary.LittleEndian.PutUint64(data, 42)
+
+	got, err := decodeCache(data)
+	if err != nil {
+		t.Fatalf("decodeCache error: %v", err)
+	}
+	if !got.Legacy || got.InputsHash != 42 {
+		t.Fatalf("decoded legacy record = %+v", got)
+	}
+}
+
+func TestCacheCorrupt(t *testing.T) {
+	t.Parallel()
+
+	data, err := encodeCache(&cacheRecord{ToolVersion: "dev", InputsHash: 7})
+	if err != nil {
+		t.Fatalf("encodeCache error: %v", err)
+	}
+
+	data[len(cacheMagic)+3] ^= 0xff
+	if _, err := decodeCache(data); !errors.Is(err, errCacheCorrupt) {
+		t.Fatalf("decodeCache error = %v, want errCacheCorrupt", err)
+	}
+}
+
+func TestComputeInputsHashParallel(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for i := range 40 {
+		path := filepath.Join(dir, fmt.Sprintf("img_%02d.png", i))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("pixels %d", i)), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &CmdPack{}
+	opts.Args.Input = dir
+	files, err := discoverImageFiles(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _, err := computeInputsHash(context.Background(), opts, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		got, _, err := computeInputsHash(context.Background(), opts, files, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("inputs hash = %016x, want stable %016x", got, want)
+		}
+	}
+
+	if err := os.WriteFile(files[17].path, []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := computeInputsHash(context.Background(), opts, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == want {
+		t.Fatal("inputs hash did not change after a file changed")
+	}
+
+	if err := os.Remove(files[3].path); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := computeInputsHash(context.Background(), opts, files, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("hash of removed input = %v, want ErrNotExist", err)
+	}
+}
+
+func TestParallelForStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	var calls atomic.Int64
+	err := parallelFor(context.Background(), 1000, 4, func(i int) error {
+		calls.Add(1)
+		if i == 10 {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("parallelFor = %v, want boom", err)
+	}
+	if calls.Load() == 1000 {
+		t.Fatal("parallelFor kept going after an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := parallelFor(ctx, 10, 2, func(int) error { return nil }); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("parallelFor on canceled ctx = %v, want ErrInterrupted", err)
+	}
+}
+
+func TestComputeInputsHashStatMode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "a.png"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &CmdPack{CacheMode: cacheModeStat}
+	opts.Args.Input = dir
+	files, err := discoverImageFiles(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, records, err := computeInputsHash(context.Background(), opts, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].ModTime == 0 || records[1].ModTime != 0 {
+		t.Fatalf("recorded mtimes = %d, %d; want a.png kept and fresh b.png cleared", records[0].ModTime, records[1].ModTime)
+	}
+
+	// A matching size and mtime reuses the recorded hash without reading.
+	known := map[string]cacheFile{"a.png": records[0], "b.png": records[1]}
+	stale := records[0]
+	stale.Hash++
+	known["a.png"] = stale
+	got, _, err := computeInputsHash(context.Background(), opts, files, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == want {
+		t.Fatal("stat match did not reuse the recorded hash")
+	}
+
+	// A changed mtime forces a rehash.
+	stale.ModTime--
+	known["a.png"] = stale
+	got, _, err = computeInputsHash(context.Background(), opts, files, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("inputs hash after mtime change = %016x, want %016x", got, want)
+	}
+}
+
+func TestComputeSettingsHashRunFlags(t *testing.T) {
+	t.Parallel()
+
+	hash := func(opts *CmdPack) uint64 {
+		t.Helper()
+		h, err := computeSettingsHash(opts)
+		if err != nil {
+			t.Fatalf("computeSettingsHash: %v", err)
+		}
+		return h
+	}
+
+	base := hash(&CmdPack{Name: "ui"})
+	if got := hash(&CmdPack{Name: "ui", Packing: PackPackingFlags{Explain: true}}); got != base {
+		t.Fatal("--explain changed the settings hash")
+	}
+	if got := hash(&CmdPack{Name: "ui", Packing: PackPackingFlags{Gap: 2}}); got == base {
+		t.Fatal("--gap did not change the settings hash")
+	}
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset-packer/internal/packer"
+)
+
+// layoutOptions holds pack layout options atlasforge.Pack does not cover.
+type layoutOptions struct {
+	// frozen placements keep their coordinates; see resolveFrozen.
+	frozen []atlasforge.Placement
+	// minWidth and minHeight keep the atlas at least this large.
+	minWidth  int
+	minHeight int
+	// blockAlign grows sprite slots to multiples of this many pixels.
+	blockAlign int
+	// items overrides gap and rotation of sprites by ID; see
+	// resolveSpriteOptions.
+	items map[string]packer.ItemOptions
+	// extrude repeats edge pixels of sprites this far into their gap,
+	// by sprite ID.
+	extrude map[string]int
+}
+
+// padding returns the gap around the sprite with id, gap unless a group
+// overrides it.
+func (lo layoutOptions) padding(id string, gap int) int {
+	if itemOpts, ok := lo.items[id]; ok {
+		return itemOpts.Padding
+	}
+
+	return gap
+}
+
+// packAtlas packs sprites into an atlas and renders it; see planAtlas.
+func packAtlas(sprites []atlasforge.Sprite, cfg atlasforge.Options, lo layoutOptions) (*atlasforge.Atlas, error) {
+	layout, err := planAtlas(sprites, cfg, lo)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]atlasforge.Source, len(sprites))
+	for i, sprite := range sprites {
+		sources[i] = atlasforge.Source{ID: sprite.ID, Image: sprite.Image}
+	}
+
+	img, err := atlasforge.Render(layout, sources)
+	if err != nil {
+		return nil, err
+	}
+	if rgba, ok := img.(*image.RGBA); ok && len(lo.extrude) > 0 {
+		for _, p := range layout.Placements {
+			w, h := p.Width, p.Height
+			if p.Rotated {
+				w, h = h, w
+			}
+			extrudeEdges(rgba, image.Rect(p.X, p.Y, p.X+w, p.Y+h), lo.extrude[p.ID])
+		}
+	}
+
+	return &atlasforge.Atlas{Image: img, Layout: *layout}, nil
+}
+
+// planAtlas places sprites without rendering. With blockAlign > 1 every
+// slot, a sprite plus the gap on both sides, is grown to a multiple of
+// blockAlign pixels. Slots then start on block boundaries of the
+// power-of-two atlas, so no compression block holds pixels of two sprites.
+// Frozen sprites are placed as given and the rest are packed around them.
+func planAtlas(sprites []atlasforge.Sprite, cfg atlasforge.Options, lo layoutOptions) (*atlasforge.Layout, error) {
+	items, sizes := layoutItems(sprites, cfg, lo)
+
+	var layout *atlasforge.Layout
+	var err error
+	if len(lo.frozen) == 0 && lo.minWidth == 0 && lo.minHeight == 0 && len(lo.items) == 0 {
+		layout, err = atlasforge.Plan(items, cfg)
+		if err != nil {
+			err = diagnosePlacement(err, sprites, cfg, lo)
+		}
+	} else {
+		layout, err = packer.Plan(items, lo.plannerOptions(cfg))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Sprites keep the top-left corner of their grown slot, also when
+	// rotated, so only the sizes change back.
+	for i := range layout.Placements {
+		p := &layout.Placements[i]
+		if size, ok := sizes[p.ID]; ok {
+			p.Width, p.Height = size[0], size[1]
+		}
+	}
+
+	return layout, nil
+}
+
+// layoutItems returns planner items for sprites that are not frozen, grown
+// to aligned slots, and the original sprite sizes by ID.
+func layoutItems(sprites []atlasforge.Sprite, cfg atlasforge.Options, lo layoutOptions) ([]atlasforge.Item, map[string][2]int) {
+	frozen := make(map[string]bool, len(lo.frozen))
+	for _, p := range lo.frozen {
+		frozen[p.ID] = true
+	}
+
+	items := make([]atlasforge.Item, 0, len(sprites))
+	sizes := make(map[string][2]int, len(sprites))
+	for _, sprite := range sprites {
+		if frozen[sprite.ID] {
+			continue
+		}
+
+		b := sprite.Image.Bounds()
+		padding := lo.padding(sprite.ID, cfg.Padding)
+		items = append(items, atlasforge.Item{
+			ID:     sprite.ID,
+			Width:  alignSlot(b.Dx(), padding, lo.blockAlign),
+			Height: alignSlot(b.Dy(), padding, lo.blockAlign),
+		})
+		sizes[sprite.ID] = [2]int{b.Dx(), b.Dy()}
+	}
+
+	return items, sizes
+}
+
+// plannerOptions returns internal planner options for cfg.
+func (lo layoutOptions) plannerOptions(cfg atlasforge.Options) packer.Options {
+	return packer.Options{
+		Options:   cfg,
+		Frozen:    lo.frozen,
+		Items:     lo.items,
+		MinWidth:  lo.minWidth,
+		MinHeight: lo.minHeight,
+	}
+}
+
+// diagnosePlacement replaces an atlasforge placement failure with the
+// *packer.PlacementError of the same search, which carries the bin state.
+// Other errors are returned unchanged.
+func diagnosePlacement(err error, sprites []atlasforge.Sprite, cfg atlasforge.Options, lo layoutOptions) error {
+	if !errors.Is(err, atlasforge.ErrPlacementFailed) {
+		return err
+	}
+
+	items, _ := layoutItems(sprites, cfg, lo)
+	_, planErr := packer.Plan(items, lo.plannerOptions(cfg))
+	var placement *packer.PlacementError
+	if errors.As(planErr, &placement) {
+		return placement
+	}
+
+	return err
+}
+
+// printExplain prints the atlas size search for sprites, one line per
+// candidate size.
+func printExplain(sprites []atlasforge.Sprite, cfg atlasforge.Options, lo layoutOptions) error {
+	items, _ := layoutItems(sprites, cfg, lo)
+	ex, err := packer.Explain(items, lo.plannerOptions(cfg))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Size search: %d items, %d px padded area, largest %dx%d\n",
+		len(items)+len(lo.frozen), ex.Area, ex.LargestWidth, ex.LargestHeight)
+	for _, c := range ex.Candidates {
+		size := fmt.Sprintf("%dx%d", c.Width, c.Height)
+		if c.Chosen {
+			fmt.Printf("  %-11s chosen, score %.0f\n", size, c.Score)
+		} else {
+			fmt.Printf("  %-11s rejected: %s\n", size, c.Reason)
+		}
+	}
+	if ex.Width == 0 {
+		fmt.Printf("  no size up to --max-size %d fits; larger sizes are over the limit\n", cfg.MaxSize)
+	}
+
+	return nil
+}
+
+// alignSlot returns the item size that makes size plus padding on both
+// sides a multiple of align.
+func alignSlot(size, padding, align int) int {
+	if align <= 1 {
+		return size
+	}
+
+	slot := size + 2*padding
+	return (slot+align-1)/align*align - 2*padding
+}
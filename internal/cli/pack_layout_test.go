@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+func TestPackAtlasBlockAlign(t *testing.T) {
+	t.Parallel()
+
+	var sprites []atlasforge.Sprite
+	for i, size := range [][2]int{{13, 7}, {30, 30}, {5, 21}, {64, 3}, {17, 17}, {9, 40}} {
+		sprites = append(sprites, atlasforge.Sprite{
+			ID:    fmt.Sprintf("s%d", i),
+			Image: image.NewNRGBA(image.Rect(0, 0, size[0], size[1])),
+		})
+	}
+
+	for _, gap := range []int{0, 1, 2} {
+		cfg := atlasforge.DefaultOptions()
+		cfg.MinSize = 64
+		cfg.Padding = gap
+		cfg.AllowRotate = true
+
+		atlas, err := packAtlas(sprites, cfg, layoutOptions{blockAlign: 4})
+		if err != nil {
+			t.Fatalf("gap %d: packAtlas: %v", gap, err)
+		}
+		for _, p := range atlas.Layout.Placements {
+			if (p.X-gap)%4 != 0 || (p.Y-gap)%4 != 0 {
+				t.Fatalf("gap %d: slot of %s starts at (%d,%d)", gap, p.ID, p.X-gap, p.Y-gap)
+			}
+			b := sprites[p.ID[1]-'0'].Image.Bounds()
+			if p.Width != b.Dx() || p.Height != b.Dy() {
+				t.Fatalf("gap %d: %s placed as %dx%d, want %dx%d", gap, p.ID, p.Width, p.Height, b.Dx(), b.Dy())
+			}
+		}
+	}
+}
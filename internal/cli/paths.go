@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// separatorReplacer turns both path separators into '_', so names read from
+// imagesets or atlas data never become nested or platform dependent paths.
+var separatorReplacer = strings.NewReplacer("/", "_", `\`, "_")
+
+// slashPath converts Windows separators to '/'. Config files and atlas data
+// are often authored on Windows and read on Linux, where filepath functions
+// treat '\' as an ordinary character.
+func slashPath(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// isWindowsAbs reports drive ("C:/...", "C:...") and UNC ("//server/...")
+// paths after slashPath, which only resolve on Windows.
+func isWindowsAbs(p string) bool {
+	if len(p) >= 2 && p[1] == ':' && (p[0]|0x20) >= 'a' && (p[0]|0x20) <= 'z' {
+		return true
+	}
+
+	return strings.HasPrefix(p, "//")
+}
+
+// normalizeConfigPath converts a path from a config file to the separators
+// of this platform. Windows-only paths fail elsewhere instead of becoming
+// odd relative paths.
+func normalizeConfigPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", nil
+	}
+
+	p = slashPath(p)
+	if runtime.GOOS != "windows" && isWindowsAbs(p) {
+		return "", fmt.Errorf("%q is a Windows path; use a relative path or one valid on %s", p, runtime.GOOS)
+	}
+
+	return filepath.Clean(filepath.FromSlash(p)), nil
+}
+
+// validateTexturePath checks an --edds-path prefix. The game resolves
+// texture paths relative to the mod root, so absolute paths and '..'
+// segments never resolve there.
+func validateTexturePath(prefix string) error {
+	p := slashPath(strings.TrimSpace(prefix))
+	if isWindowsAbs(p) {
+		return fmt.Errorf("invalid --edds-path %q: use a path relative to the mod root, e.g. mod/data/images", prefix)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return fmt.Errorf("invalid --edds-path %q: '..' segments are not allowed", prefix)
+		}
+	}
+
+	return nil
+}
+
+// cleanTexturePath returns an --edds-path prefix with '/' separators and
+// without empty, '.' and leading or trailing segments.
+func cleanTexturePath(prefix string) string {
+	p := strings.Trim(path.Clean("/"+slashPath(strings.TrimSpace(prefix))), "/")
+	if p == "." {
+		return ""
+	}
+
+	return p
+}
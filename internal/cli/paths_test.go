@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFormatEddsRefPathSeparators(t *testing.T) {
+	t.Parallel()
+
+	for prefix, want := range map[string]string{
+		"":                      "icons.edds",
+		`\`:                     "icons.edds",
+		`mod\data\images`:       "mod/data/images/icons.edds",
+		`mod\\data\\images\\`:   "mod/data/images/icons.edds",
+		"./mod//data/./images/": "mod/data/images/icons.edds",
+	} {
+		if got := formatEddsRefPath(prefix, "icons"); got != want {
+			t.Errorf("formatEddsRefPath(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	for _, prefix := range []string{`P:\mod\data`, "mod/../other", `\\server\share`} {
+		if err := validateTexturePath(prefix); err == nil {
+			t.Errorf("validateTexturePath(%q): expected error", prefix)
+		}
+	}
+}
+
+func TestNormalizeProjectPaths(t *testing.T) {
+	t.Parallel()
+
+	base := filepath.Join("cfg", "root")
+	cfg := &CmdPack{Path: `mod\data\images`}
+	cfg.Args.Input = `.\art\icons`
+	cfg.Args.Output = `out\images\`
+	cfg.Input.RenameMap = "renames.yaml"
+	if err := normalizeProjectPaths(cfg, base); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(base, "art", "icons"); cfg.Args.Input != want {
+		t.Fatalf("input = %q, want %q", cfg.Args.Input, want)
+	}
+	if want := filepath.Join(base, "out", "images"); cfg.Args.Output != want {
+		t.Fatalf("output = %q, want %q", cfg.Args.Output, want)
+	}
+	if want := filepath.Join(base, "renames.yaml"); cfg.Input.RenameMap != want {
+		t.Fatalf("rename map = %q, want %q", cfg.Input.RenameMap, want)
+	}
+
+	if runtime.GOOS != "windows" {
+		cfg.Args.Output = `P:\mod\data\images`
+		if err := normalizeProjectPaths(cfg, base); err == nil {
+			t.Fatal("expected error for a drive path")
+		}
+	}
+}
+
+func TestSpriteNameWindowsSeparators(t *testing.T) {
+	t.Parallel()
+
+	name, group := spriteName(`hud\icons\ok.png`)
+	if name != "ok" || group != "hud_icons" {
+		t.Fatalf("got %q in %q, want ok in hud_icons", name, group)
+	}
+	if name, _ := spriteName("hud/icon_v1.5"); name != "icon_v1.5" {
+		t.Fatalf("spriteName kept %q, want icon_v1.5", name)
+	}
+	if got := sanitizeName(`hud\..\icons`); got != "hud_._icons" {
+		t.Fatalf("sanitizeName = %q", got)
+	}
+}
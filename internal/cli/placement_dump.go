@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset-packer/internal/packer"
+)
+
+// freeRectColor outlines free rectangles in the placement preview.
+var freeRectColor = color.NRGBA{R: 255, G: 0, B: 255, A: 255}
+
+// placementHint returns advice for a placement failure.
+func placementHint(pe *packer.PlacementError) string {
+	if pe.NextWidth == 0 {
+		return "no atlas up to 16384x16384 fits all sprites; split the input or use --max-input-side"
+	}
+
+	return fmt.Sprintf("all sprites fit into %dx%d; raise --max-size to %d", pe.NextWidth, pe.NextHeight, max(pe.NextWidth, pe.NextHeight))
+}
+
+// placementDumpPaths returns the text and image paths of the placement
+// failure dump of the project name in dir.
+func placementDumpPaths(dir, name string) (text, image string) {
+	return filepath.Join(dir, name+".placement.txt"), filepath.Join(dir, name+".placement.png")
+}
+
+// writePlacementDump writes <name>.placement.txt with the bin state of a
+// placement failure and <name>.placement.png with the sprites placed so
+// far and the free rectangles outlined. It returns the written paths.
+func writePlacementDump(dir, name string, sprites []atlasforge.Sprite, pe *packer.PlacementError) ([]string, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Item %q (%dx%d with gap) did not fit into %dx%d.\n", pe.ID, pe.ItemWidth, pe.ItemHeight, pe.Width, pe.Height)
+	fmt.Fprintf(&b, "Hint: %s.\n\n", placementHint(pe))
+	fmt.Fprintf(&b, "Placed (%d):\n", len(pe.Placed))
+	for _, p := range pe.Placed {
+		rotated := ""
+		if p.Rotated {
+			rotated = " rotated"
+		}
+		fmt.Fprintf(&b, "  %s %d,%d %dx%d%s\n", p.ID, p.X, p.Y, p.Width, p.Height, rotated)
+	}
+	fmt.Fprintf(&b, "\nFree rectangles (%d):\n", len(pe.Free))
+	for _, r := range pe.Free {
+		fmt.Fprintf(&b, "  %d,%d %dx%d\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+
+	textPath, imagePath := placementDumpPaths(dir, name)
+	if err := os.WriteFile(textPath, []byte(b.String()), 0600); err != nil {
+		return nil, err
+	}
+
+	preview, err := renderPlacementPreview(sprites, pe)
+	if err != nil {
+		return []string{textPath}, err
+	}
+	f, err := os.Create(imagePath)
+	if err != nil {
+		return []string{textPath}, err
+	}
+	if err := png.Encode(f, preview); err != nil {
+		_ = f.Close()
+		return []string{textPath}, err
+	}
+	if err := f.Close(); err != nil {
+		return []string{textPath}, err
+	}
+
+	return []string{textPath, imagePath}, nil
+}
+
+// renderPlacementPreview renders the sprites placed before a failure and
+// outlines the free rectangles.
+func renderPlacementPreview(sprites []atlasforge.Sprite, pe *packer.PlacementError) (*image.NRGBA, error) {
+	sources := make([]atlasforge.Source, len(sprites))
+	bounds := make(map[string]image.Rectangle, len(sprites))
+	for i, sprite := range sprites {
+		sources[i] = atlasforge.Source{ID: sprite.ID, Image: sprite.Image}
+		bounds[sprite.ID] = sprite.Image.Bounds()
+	}
+
+	// Placements may hold block aligned slot sizes; render sprite sizes.
+	layout := &atlasforge.Layout{Width: pe.Width, Height: pe.Height}
+	for _, p := range pe.Placed {
+		b := bounds[p.ID]
+		p.Width, p.Height = b.Dx(), b.Dy()
+		layout.Placements = append(layout.Placements, p)
+	}
+	rendered, err := atlasforge.Render(layout, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := image.NewNRGBA(rendered.Bounds())
+	draw.Draw(preview, preview.Bounds(), rendered, image.Point{}, draw.Src)
+	for _, r := range pe.Free {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			preview.SetNRGBA(x, r.Min.Y, freeRectColor)
+			preview.SetNRGBA(x, r.Max.Y-1, freeRectColor)
+		}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			preview.SetNRGBA(r.Min.X, y, freeRectColor)
+			preview.SetNRGBA(r.Max.X-1, y, freeRectColor)
+		}
+	}
+
+	return preview, nil
+}
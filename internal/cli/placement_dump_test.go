@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"errors"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/imageset-packer/internal/packer"
+)
+
+func TestPackAtlasPlacementDump(t *testing.T) {
+	t.Parallel()
+
+	var sprites []atlasforge.Sprite
+	for _, id := range []string{"a", "b", "c"} {
+		sprites = append(sprites, atlasforge.Sprite{ID: id, Image: image.NewNRGBA(image.Rect(0, 0, 600, 500))})
+	}
+	sprites[0].Image = image.NewNRGBA(image.Rect(0, 0, 513, 513))
+
+	cfg := atlasforge.DefaultOptions()
+	cfg.MinSize = 64
+	cfg.MaxSize = 1024
+
+	_, err := packAtlas(sprites, cfg, layoutOptions{})
+	var pe *packer.PlacementError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v, want *packer.PlacementError", err)
+	}
+
+	paths, err := writePlacementDump(t.TempDir(), "test", sprites, pe)
+	if err != nil {
+		t.Fatalf("writePlacementDump: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got paths %q", paths)
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Fatalf("dump %s missing or empty: %v", path, err)
+		}
+	}
+}
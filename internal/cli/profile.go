@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formatProfile holds companion settings filled in for an output format
+// when the matching option is left at its default.
+type formatProfile struct {
+	// Quality replaces --quality 0.
+	Quality int
+	// BlockAlign replaces --block-align 0.
+	BlockAlign int
+}
+
+// formatProfiles maps --out-format values to their profile. DXT formats
+// get high quality and slots aligned to 4x4 blocks, so no block mixes
+// pixels of two sprites; bgra8 is lossless and needs neither.
+var formatProfiles = map[string]formatProfile{
+	"bgra8": {},
+	"dxt1":  {Quality: 8, BlockAlign: 4},
+	"dxt5":  {Quality: 8, BlockAlign: 4},
+}
+
+// packProfile is the result of applying a format profile to pack flags.
+type packProfile struct {
+	// notes describe every applied or ignored setting, for reporting.
+	notes      []string
+	quality    int
+	blockAlign int
+}
+
+// resolvePackProfile returns effective quality and block alignment for
+// pack flags. Explicit non-zero values always win over the profile.
+func resolvePackProfile(flags *PackPackingFlags) packProfile {
+	p := packProfile{quality: flags.Quality, blockAlign: flags.BlockAlign}
+	format := strings.ToLower(flags.OutputFormat)
+
+	if format == "bgra8" && flags.Quality != 0 {
+		p.notes = append(p.notes, fmt.Sprintf("quality %d has no effect on bgra8", flags.Quality))
+	}
+	if flags.NoFormatProfile {
+		return p
+	}
+
+	profile := formatProfiles[format]
+	if p.quality == 0 && profile.Quality != 0 {
+		p.quality = profile.Quality
+		p.notes = append(p.notes, fmt.Sprintf("quality %d", p.quality))
+	}
+	if p.blockAlign == 0 && profile.BlockAlign != 0 {
+		p.blockAlign = profile.BlockAlign
+		p.notes = append(p.notes, fmt.Sprintf("block align %d", p.blockAlign))
+	}
+
+	return p
+}
+
+// String returns a one-line report of the profile, or "" if nothing applied.
+func (p packProfile) String() string {
+	if len(p.notes) == 0 {
+		return ""
+	}
+
+	return strings.Join(p.notes, ", ")
+}
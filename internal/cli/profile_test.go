@@ -0,0 +1,31 @@
+package cli
+
+import "testing"
+
+func TestResolvePackProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		flags       PackPackingFlags
+		wantQuality int
+		wantAlign   int
+		wantReport  bool
+	}{
+		{name: "bgra8", flags: PackPackingFlags{OutputFormat: "bgra8"}},
+		{name: "bgra8 quality", flags: PackPackingFlags{OutputFormat: "bgra8", Quality: 5}, wantQuality: 5, wantReport: true},
+		{name: "dxt5", flags: PackPackingFlags{OutputFormat: "dxt5"}, wantQuality: 8, wantAlign: 4, wantReport: true},
+		{name: "dxt1 explicit", flags: PackPackingFlags{OutputFormat: "dxt1", Quality: 3, BlockAlign: 8}, wantQuality: 3, wantAlign: 8},
+		{name: "dxt5 disabled", flags: PackPackingFlags{OutputFormat: "dxt5", NoFormatProfile: true}},
+	}
+
+	for _, tt := range tests {
+		got := resolvePackProfile(&tt.flags)
+		if got.quality != tt.wantQuality || got.blockAlign != tt.wantAlign {
+			t.Fatalf("%s: quality %d, align %d; want %d, %d", tt.name, got.quality, got.blockAlign, tt.wantQuality, tt.wantAlign)
+		}
+		if (got.String() != "") != tt.wantReport {
+			t.Fatalf("%s: report %q, want report %v", tt.name, got.String(), tt.wantReport)
+		}
+	}
+}
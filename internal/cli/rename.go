@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/woozymasta/imageset"
+	"gopkg.in/yaml.v3"
+)
+
+// spriteRename is one old -> new entry of a rename map.
+type spriteRename struct {
+	Old string
+	New string
+}
+
+// readRenameMap reads a YAML mapping of old to new sprite names, sorted by
+// old name. Names are compared after imageset name normalization; chained
+// renames (a new name renamed again) are rejected.
+func readRenameMap(path string) ([]spriteRename, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rename map: %w", err)
+	}
+
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse rename map %q: %w", path, err)
+	}
+
+	renames := make([]spriteRename, 0, len(raw))
+	olds := make(map[string]string, len(raw))
+	for oldName, newName := range raw {
+		oldName, newName = strings.TrimSpace(oldName), strings.TrimSpace(newName)
+		if oldName == "" || newName == "" {
+			return nil, fmt.Errorf("rename map %q: empty name in %q: %q", path, oldName, newName)
+		}
+
+		key := imageset.NormalizeName(oldName, false)
+		if key == imageset.NormalizeName(newName, false) {
+			return nil, fmt.Errorf("rename map %q: %q renames to itself", path, oldName)
+		}
+		if prev, ok := olds[key]; ok {
+			return nil, fmt.Errorf("rename map %q: %q and %q are the same name", path, prev, oldName)
+		}
+		olds[key] = oldName
+		renames = append(renames, spriteRename{Old: oldName, New: newName})
+	}
+	for _, r := range renames {
+		if prev, ok := olds[imageset.NormalizeName(r.New, false)]; ok {
+			return nil, fmt.Errorf("rename map %q: %q is renamed to %q, which is renamed again; map %q to the final name", path, r.Old, r.New, prev)
+		}
+	}
+
+	sort.Slice(renames, func(i, j int) bool { return renames[i].Old < renames[j].Old })
+	return renames, nil
+}
+
+// addRenameAliases adds an entry under each old name with the rect of the
+// sprite now called new, in the same group, so references to old names keep
+// working. Names are resolved within each group, so a name used in several
+// groups gets an alias in each of them. It returns notes for entries that
+// could not be applied.
+func addRenameAliases(doc *imageset.Document, renames []spriteRename) []string {
+	type spriteList struct {
+		images *[]imageset.Image
+		byName map[string]int
+	}
+	lists := make([]spriteList, 0, len(doc.Groups)+1)
+	index := func(images *[]imageset.Image) {
+		byName := make(map[string]int, len(*images))
+		for i, img := range *images {
+			byName[imageset.NormalizeName(img.Name, false)] = i
+		}
+		lists = append(lists, spriteList{images: images, byName: byName})
+	}
+	index(&doc.Images)
+	for g := range doc.Groups {
+		index(&doc.Groups[g].Images)
+	}
+
+	var notes []string
+	for _, r := range renames {
+		oldKey, newKey := imageset.NormalizeName(r.Old, false), imageset.NormalizeName(r.New, false)
+		applied, input := false, false
+		for _, list := range lists {
+			if _, ok := list.byName[oldKey]; ok {
+				input = true
+				continue
+			}
+			i, ok := list.byName[newKey]
+			if !ok {
+				continue
+			}
+
+			alias := (*list.images)[i]
+			alias.Name = r.Old
+			*list.images = append(*list.images, alias)
+			applied = true
+		}
+
+		switch {
+		case input:
+			notes = append(notes, fmt.Sprintf("rename %s -> %s skipped: %s is still an input", r.Old, r.New, r.Old))
+		case !applied:
+			notes = append(notes, fmt.Sprintf("rename %s -> %s skipped: no sprite named %s", r.Old, r.New, r.New))
+		}
+	}
+
+	return notes
+}
+
+// applyRenames returns defs with old names replaced by new ones for
+// extraction. An old entry is dropped when its new name is also present,
+// as it is then an alias written by pack.
+func applyRenames(defs []imageset.Image, renames []spriteRename) []imageset.Image {
+	if len(renames) == 0 {
+		return defs
+	}
+
+	newNames := make(map[string]string, len(renames))
+	for _, r := range renames {
+		newNames[imageset.NormalizeName(r.Old, false)] = r.New
+	}
+	present := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		present[imageset.NormalizeName(def.Name, false)] = true
+	}
+
+	out := make([]imageset.Image, 0, len(defs))
+	for _, def := range defs {
+		if newName, ok := newNames[imageset.NormalizeName(def.Name, false)]; ok {
+			if present[imageset.NormalizeName(newName, false)] {
+				continue
+			}
+			def.Name = newName
+		}
+		out = append(out, def)
+	}
+
+	return out
+}
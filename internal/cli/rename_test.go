@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/imageset"
+)
+
+func TestReadRenameMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "renames.yaml")
+	writeTestFile(t, path, "# art renames\nold_ok: icon_ok\nOldCancel: icon_cancel\n")
+
+	renames, err := readRenameMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []spriteRename{{Old: "OldCancel", New: "icon_cancel"}, {Old: "old_ok", New: "icon_ok"}}
+	if len(renames) != 2 || renames[0] != want[0] || renames[1] != want[1] {
+		t.Fatalf("got %+v, want %+v", renames, want)
+	}
+
+	for content, wantErr := range map[string]string{
+		"a: b\nb: c\n":   "renamed again",
+		"icon-a: icon_a": "to itself",
+		"a: ''\n":        "empty name",
+	} {
+		writeTestFile(t, path, content)
+		if _, err := readRenameMap(path); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("readRenameMap(%q) = %v, want %q", content, err, wantErr)
+		}
+	}
+}
+
+func TestRenameAliasesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	doc := &imageset.Document{
+		Images: []imageset.Image{{Name: "icon_ok", Pos: imageset.Point{X: 4}, Size: imageset.Size{Width: 8, Height: 8}}},
+		Groups: []imageset.Group{{Name: "hud", Images: []imageset.Image{
+			{Name: "icon_cancel", Pos: imageset.Point{X: 16}, Size: imageset.Size{Width: 8, Height: 8}},
+			{Name: "still_here", Pos: imageset.Point{X: 32}, Size: imageset.Size{Width: 8, Height: 8}},
+		}}},
+	}
+	renames := []spriteRename{
+		{Old: "old_cancel", New: "icon_cancel"},
+		{Old: "old_ok", New: "icon_ok"},
+		{Old: "still_here", New: "moved"},
+		{Old: "stale", New: "missing"},
+	}
+
+	notes := addRenameAliases(doc, renames)
+	if len(notes) != 2 || !strings.Contains(notes[0], "still an input") || !strings.Contains(notes[1], "no sprite named missing") {
+		t.Fatalf("notes = %q", notes)
+	}
+	if len(doc.Images) != 2 || doc.Images[1].Name != "old_ok" || doc.Images[1].Pos != doc.Images[0].Pos {
+		t.Fatalf("root images = %+v", doc.Images)
+	}
+	group := doc.Groups[0].Images
+	if len(group) != 3 || group[2].Name != "old_cancel" || group[2].Pos.X != 16 {
+		t.Fatalf("group images = %+v", group)
+	}
+
+	// A name used in two groups gets an alias in each group.
+	dup := &imageset.Document{Groups: []imageset.Group{
+		{Name: "hud", Images: []imageset.Image{{Name: "ok", Pos: imageset.Point{X: 1}}}},
+		{Name: "menu", Images: []imageset.Image{{Name: "ok", Pos: imageset.Point{X: 2}}}},
+	}}
+	if notes := addRenameAliases(dup, []spriteRename{{Old: "old_ok", New: "ok"}}); len(notes) != 0 {
+		t.Fatalf("duplicate name notes = %q", notes)
+	}
+	for i, g := range dup.Groups {
+		if len(g.Images) != 2 || g.Images[1].Name != "old_ok" || g.Images[1].Pos.X != i+1 {
+			t.Fatalf("group %s images = %+v", g.Name, g.Images)
+		}
+	}
+
+	// Extraction drops aliases and writes entries of old imagesets under
+	// their new names.
+	if got := applyRenames(doc.Images, renames); len(got) != 1 || got[0].Name != "icon_ok" {
+		t.Fatalf("applyRenames(with alias) = %+v", got)
+	}
+	old := []imageset.Image{{Name: "old_ok"}, {Name: "other"}}
+	if got := applyRenames(old, renames); len(got) != 2 || got[0].Name != "icon_ok" || got[1].Name != "other" {
+		t.Fatalf("applyRenames(old names) = %+v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// retryPolicy controls retries of transient IO failures.
+type retryPolicy struct {
+	// Delay is the wait before the first retry; it doubles after each attempt.
+	Delay time.Duration
+	// Retries is the number of extra attempts after the first failure.
+	Retries int
+	// Warn reports a retry; nil prints a warning to stderr.
+	Warn func(format string, args ...any)
+}
+
+// newRetryPolicy builds a retry policy from pack IO flags.
+func newRetryPolicy(opts *PackIOFlags) retryPolicy {
+	return retryPolicy{
+		Delay:   opts.RetryDelay,
+		Retries: max(opts.Retries, 0),
+	}
+}
+
+// Do runs fn and retries it with exponential backoff while it fails with
+// a transient IO error. what names the operation in retry messages.
+func (p retryPolicy) Do(ctx context.Context, what string, fn func() error) error {
+	delay := p.Delay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Retries || !isTransientIOError(err) {
+			return err
+		}
+
+		if p.Warn != nil {
+			p.Warn("%s failed: %v; retrying in %s (%d/%d)", what, err, delay, attempt+1, p.Retries)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s failed: %v; retrying in %s (%d/%d)\n", what, err, delay, attempt+1, p.Retries)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ErrInterrupted
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+// isTransientIOError reports whether err looks like a recoverable filesystem
+// failure (e.g. a dropped network share) rather than a permanent condition
+// such as a missing file, denied access, a full disk or a decode error.
+// transientErrnos lists the recoverable errors of the target OS.
+func isTransientIOError(err error) bool {
+	if errors.Is(err, ErrInterrupted) {
+		return false
+	}
+
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+
+	return slices.Contains(transientErrnos, errno)
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestRetryPolicyDo(t *testing.T) {
+	t.Parallel()
+
+	transient := &fs.PathError{Op: "read", Path: "x.png", Err: transientErrnos[0]}
+	policy := retryPolicy{Retries: 2}
+
+	calls := 0
+	err := policy.Do(context.Background(), "read", func() error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Do = %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	missing := &fs.PathError{Op: "open", Path: "x.png", Err: fs.ErrNotExist}
+	err = policy.Do(context.Background(), "read", func() error {
+		calls++
+		return missing
+	})
+	if !errors.Is(err, fs.ErrNotExist) || calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want no retry for missing file", err, calls)
+	}
+}
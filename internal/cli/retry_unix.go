@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cli
+
+import "syscall"
+
+// transientErrnos are errors of a filesystem or network share that may go
+// away on retry. Everything else, such as a missing file, a directory
+// instead of a file or a name that is too long, fails the same way again.
+var transientErrnos = []syscall.Errno{
+	syscall.EIO,
+	syscall.EAGAIN,
+	syscall.EBUSY,
+	syscall.ETIMEDOUT,
+	syscall.ESTALE,
+	syscall.ECONNRESET,
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsTransientIOErrorUnix(t *testing.T) {
+	t.Parallel()
+
+	for _, errno := range []syscall.Errno{syscall.ENOENT, syscall.EISDIR, syscall.EINVAL, syscall.ENAMETOOLONG} {
+		if isTransientIOError(&os.PathError{Op: "open", Path: "x.png", Err: errno}) {
+			t.Fatalf("%v is retried as transient", errno)
+		}
+	}
+	if !isTransientIOError(&os.PathError{Op: "read", Path: "x.png", Err: syscall.ESTALE}) {
+		t.Fatal("ESTALE is not retried")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package cli
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// transientErrnos are Win32 errors of a dropped or busy SMB share and of
+// files briefly held by another process. Windows never returns the POSIX
+// errnos of the syscall package, so they are not listed.
+var transientErrnos = []syscall.Errno{
+	windows.ERROR_SHARING_VIOLATION,
+	windows.ERROR_LOCK_VIOLATION,
+	windows.ERROR_BAD_NETPATH,
+	windows.ERROR_UNEXP_NET_ERR,
+	windows.ERROR_NETNAME_DELETED,
+	windows.ERROR_SEM_TIMEOUT,
+}
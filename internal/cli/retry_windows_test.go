@@ -0,0 +1,23 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestIsTransientIOErrorWindows(t *testing.T) {
+	t.Parallel()
+
+	dropped := &os.PathError{Op: "read", Path: `\\server\art\x.png`, Err: windows.ERROR_NETNAME_DELETED}
+	if !isTransientIOError(dropped) {
+		t.Fatal("ERROR_NETNAME_DELETED is not retried")
+	}
+	if isTransientIOError(&os.PathError{Op: "open", Path: "x.png", Err: syscall.ERROR_FILE_NOT_FOUND}) {
+		t.Fatal("ERROR_FILE_NOT_FOUND is retried as transient")
+	}
+}
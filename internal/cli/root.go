@@ -2,16 +2,22 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/woozymasta/imageset-packer/internal/vars"
 )
 
 // Root defines global CLI flags.
-type Root struct{}
+type Root struct {
+	Protect []string `long:"protect" env:"IMAGESET_PACKER_PROTECT" env-delim:";" description:"Refuse to write, replace or remove files under this directory, e.g. the game install (repeatable)"`
+}
 
 // CmdVersion prints build metadata.
 type CmdVersion struct{}
@@ -38,14 +44,54 @@ func Run(args []string) error {
 
 Examples:
   %s build ./my-imageset-packer-config.yaml
-  %s build --project ui --project icons`,
-			prog, prog,
+  %s build --project ui --project icons
+  %s build --list
+  %s build --graph dot | dot -Tsvg -o projects.svg`,
+			prog, prog, prog, prog,
 		),
 		&CmdBuild{},
 	); err != nil {
 		return err
 	}
 
+	if _, err := parser.AddCommand(
+		"clean",
+		"Remove generated outputs of build projects",
+		fmt.Sprintf(
+			`Remove .imageset, .edds and .imagehash files generated by build projects.
+Files are taken from the project cache when present, otherwise from
+the project output names. Wildcards are never used.
+
+Examples:
+  %s clean --dry-run
+  %s clean ./my-imageset-packer-config.yaml --project ui`,
+			prog, prog,
+		),
+		&CmdClean{},
+	); err != nil {
+		return err
+	}
+
+	if _, err := parser.AddCommand(
+		"verify",
+		"Check that outputs of build projects match their sources",
+		fmt.Sprintf(
+			`Compare outputs of build projects with their inputs without packing.
+A project is stale when outputs are missing, inputs or settings differ
+from the .imagehash cache, outputs were modified after the last pack,
+or outputs are older than a source file. Exits non-zero on stale projects.
+
+Examples:
+  %s verify
+  %s verify ./my-imageset-packer-config.yaml --project ui
+  %s verify --no-mtime`,
+			prog, prog, prog,
+		),
+		&CmdVerify{},
+	); err != nil {
+		return err
+	}
+
 	if _, err := parser.AddCommand(
 		"pack",
 		"Pack images into .imageset + .edds atlas",
@@ -64,6 +110,25 @@ Examples:
 		return err
 	}
 
+	if _, err := parser.AddCommand(
+		"import",
+		"Import an atlas layout of another tool as .imageset",
+		fmt.Sprintf(
+			`Convert a TexturePacker JSON (hash or array), Godot AtlasTexture .tres
+files or a CSV of name,x,y,width,height[,group] rects into an imageset.
+With --edds the referenced sheet is re-encoded into an EDDS texture.
+
+Examples:
+  %s import ./sheets/hud.json --edds -P mod/data/images
+  %s import ./godot/ui/atlas ./out --sheet ./godot/ui/ui.png
+  %s import rects.csv --sheet sheet.png --name icons`,
+			prog, prog, prog,
+		),
+		&CmdImport{},
+	); err != nil {
+		return err
+	}
+
 	if _, err := parser.AddCommand(
 		"unpack",
 		"Unpack .imageset + .edds into images",
@@ -82,21 +147,42 @@ Examples:
 
 	if _, err := parser.AddCommand(
 		"convert",
-		"Convert a single image file between formats",
+		"Convert image files between formats",
 		fmt.Sprintf(
-			`Convert one image between supported formats.
+			`Convert one image, or every file of a --batch mapping, between supported formats.
 
 Examples:
   %s convert icon.png icon.tga
   %s convert atlas.edds atlas.png
-  %s convert icon.png icon.edds --format dxt1 --quality 8 --mipmaps 1`,
-			prog, prog, prog,
+  %s convert icon.png icon.edds --format dxt1 --quality 8 --mipmaps 1
+  %s convert --batch textures.csv --format dxt5 --jobs 4`,
+			prog, prog, prog, prog,
 		),
 		&CmdConvert{},
 	); err != nil {
 		return err
 	}
 
+	conformance, err := parser.AddCommand(
+		"conformance",
+		"Round-trip existing imagesets and report incompatibilities",
+		fmt.Sprintf(
+			`Unpack and pack again every .imageset + .edds pair under a directory,
+then compare names, sizes and pixels with the original.
+Writes a JSON compatibility report.
+
+Examples:
+  %s conformance ./extracted/gui/imagesets
+  %s conformance ./imagesets --report compat.json --work-dir ./conformance-work`,
+			prog, prog,
+		),
+		&CmdConformance{},
+	)
+	if err != nil {
+		return err
+	}
+	conformance.Hidden = true
+
 	if _, err := parser.AddCommand(
 		"version",
 		"Print build metadata",
@@ -112,12 +198,31 @@ Examples:
 		return err
 	}
 
-	_, err := parser.ParseArgs(args)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	parser.CommandHandler = func(cmd flags.Commander, args []string) error {
+		if cmd == nil {
+			return nil
+		}
+
+		cmdCtx, err := withProtectedDirs(ctx, root.Protect)
+		if err != nil {
+			return err
+		}
+
+		return executeRecovered(cmdCtx, cmd, args)
+	}
+
+	_, err = parser.ParseArgs(args)
 
 	if err != nil {
 		if fe, ok := err.(*flags.Error); ok && fe.Type == flags.ErrHelp {
 			return nil
 		}
+		if ctx.Err() != nil && !errors.Is(err, ErrInterrupted) {
+			return fmt.Errorf("%w: %v", ErrInterrupted, err)
+		}
 		return err
 	}
 
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/woozymasta/imageset"
+	"golang.org/x/image/draw"
+)
+
+// tileManifest is the <name>.tiles.json reassembly metadata written for
+// sources split by --tile-oversized.
+type tileManifest struct {
+	Sources []tiledSource `json:"sources"`
+}
+
+// tiledSource describes one source image split into tiles.
+type tiledSource struct {
+	// Name is the source image name; Group holds its tiles in the imageset.
+	Name   string       `json:"name"`
+	Group  string       `json:"group"`
+	Tiles  []sourceTile `json:"tiles"`
+	Width  int          `json:"width"`
+	Height int          `json:"height"`
+}
+
+// sourceTile is a tile image and its offset in the source image.
+type sourceTile struct {
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// tileSide returns the largest tile side that fits an atlas of maxSize
+// with gap on both sides, rounded down to whole 4x4 blocks.
+func tileSide(maxSize, gap int) int {
+	return (maxSize - 2*gap) / 4 * 4
+}
+
+// tileOversized splits images with a side above maxSide into a grid of
+// near-equal tiles named <name>_<col>_<row>. Tiles go to the source group,
+// or to a group named after the source. It returns the new file list and
+// the reassembly metadata; names are written as the imageset writes them.
+func tileOversized(files []imageFile, maxSide int, camel bool) ([]imageFile, []tiledSource, error) {
+	if maxSide <= 0 {
+		return nil, nil, fmt.Errorf("no room for tiles in the atlas (max tile side %d)", maxSide)
+	}
+
+	names := make(map[string]string, len(files))
+	for _, f := range files {
+		names[f.name] = f.path
+	}
+
+	out := make([]imageFile, 0, len(files))
+	var tiled []tiledSource
+	for _, f := range files {
+		if f.width <= maxSide && f.height <= maxSide {
+			out = append(out, f)
+			continue
+		}
+
+		group := f.groupName
+		if group == "" {
+			group = f.name
+		}
+		src := tiledSource{
+			Name:   f.name,
+			Group:  imageset.NormalizeName(group, camel),
+			Width:  f.width,
+			Height: f.height,
+		}
+
+		cols, rows := (f.width+maxSide-1)/maxSide, (f.height+maxSide-1)/maxSide
+		tw, th := evenTile(f.width, cols, maxSide), evenTile(f.height, rows, maxSide)
+		b := f.image.Bounds()
+		for row := 0; row*th < f.height; row++ {
+			for col := 0; col*tw < f.width; col++ {
+				rect := image.Rect(col*tw, row*th, min((col+1)*tw, f.width), min((row+1)*th, f.height))
+				name := fmt.Sprintf("%s_%d_%d", f.name, col, row)
+				if prev, ok := names[name]; ok {
+					return nil, nil, fmt.Errorf("tile name %q of %q collides with %q", name, f.path, prev)
+				}
+				names[name] = f.path
+
+				tile := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+				draw.Draw(tile, tile.Bounds(), f.image, b.Min.Add(rect.Min), draw.Src)
+				out = append(out, imageFile{
+					image:     tile,
+					path:      f.path,
+					name:      name,
+					groupName: group,
+					width:     rect.Dx(),
+					height:    rect.Dy(),
+				})
+				src.Tiles = append(src.Tiles, sourceTile{
+					Name:   imageset.NormalizeName(name, camel),
+					X:      rect.Min.X,
+					Y:      rect.Min.Y,
+					Width:  rect.Dx(),
+					Height: rect.Dy(),
+				})
+			}
+		}
+		tiled = append(tiled, src)
+	}
+
+	return out, tiled, nil
+}
+
+// evenTile returns the tile side that splits size into count near-equal
+// parts, rounded up to whole 4x4 blocks but never above maxSide.
+func evenTile(size, count, maxSide int) int {
+	side := (size + count - 1) / count
+	return min((side+3)/4*4, maxSide)
+}
+
+// writeTileManifest writes tile reassembly metadata as JSON.
+func writeTileManifest(path string, tiled []tiledSource) error {
+	data, err := json.MarshalIndent(tileManifest{Sources: tiled}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
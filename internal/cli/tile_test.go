@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTileOversized(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 6))
+	for y := range 6 {
+		for x := range 10 {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), A: 255}) //nolint:gosec // small test values
+		}
+	}
+	files := []imageFile{
+		{name: "Banner", image: src, width: 10, height: 6},
+		{name: "icon", groupName: "ui", image: image.NewNRGBA(image.Rect(0, 0, 4, 4)), width: 4, height: 4},
+	}
+
+	out, tiled, err := tileOversized(files, 4, false)
+	if err != nil {
+		t.Fatalf("tileOversized: %v", err)
+	}
+	if len(out) != 7 || len(tiled) != 1 || len(tiled[0].Tiles) != 6 {
+		t.Fatalf("got %d files and %+v", len(out), tiled)
+	}
+	if tiled[0].Group != "banner" || tiled[0].Tiles[5].Name != "banner_2_1" {
+		t.Fatalf("unexpected names: %+v", tiled[0])
+	}
+
+	for i, tile := range tiled[0].Tiles {
+		f := out[i]
+		if f.groupName != "Banner" || f.width != tile.Width || f.height != tile.Height {
+			t.Fatalf("tile %d: file %+v does not match %+v", i, f, tile)
+		}
+		if f.width > 4 || f.height > 4 {
+			t.Fatalf("tile %d is %dx%d", i, f.width, f.height)
+		}
+		got := f.image.(*image.NRGBA).NRGBAAt(f.width-1, f.height-1)
+		if int(got.R) != tile.X+f.width-1 || int(got.G) != tile.Y+f.height-1 {
+			t.Fatalf("tile %d holds pixel %v", i, got)
+		}
+	}
+
+	if _, _, err := tileOversized([]imageFile{files[0], {name: "Banner_0_0"}}, 4, false); err == nil {
+		t.Fatal("expected tile name collision error")
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/draw"
@@ -8,8 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/woozymasta/edds"
 	"github.com/woozymasta/imageset"
+	"github.com/woozymasta/imageset-packer/edds"
 	"github.com/woozymasta/imageset-packer/internal/imageio"
 )
 
@@ -22,6 +23,8 @@ type CmdUnpack struct {
 
 	OutFormat  string `short:"o" long:"out-format" description:"Output format: png,tga,tiff,bmp,dds (default: png)" default:"png"`
 	OutputDir  string `short:"O" long:"output-dir" description:"Output directory (default: current dir)"`
+	RenameMap  string `long:"rename-map" description:"YAML map of old: new sprite names; entries with old names are written under the new name"`
+	Layout     string `long:"layout" description:"<name>.layout.json written by pack --layout-json; sprites stored rotated are turned back upright"`
 	Overwrite  bool   `short:"f" long:"force" description:"Overwrite existing files"`
 	KeepGroups bool   `short:"g" long:"groups" description:"Write groups into subdirectories"`
 	Dedup      bool   `short:"d" long:"deduplicate" description:"Drop duplicate entries with identical Pos/Size"`
@@ -29,16 +32,37 @@ type CmdUnpack struct {
 
 // Execute runs the unpack command.
 func (c *CmdUnpack) Execute(args []string) error {
-	return runUnpack(c)
+	return c.ExecuteContext(context.Background(), args)
 }
 
-func runUnpack(opts *CmdUnpack) error {
+// ExecuteContext runs the unpack command until ctx is canceled.
+func (c *CmdUnpack) ExecuteContext(ctx context.Context, args []string) error {
+	return runUnpack(ctx, c)
+}
+
+func runUnpack(ctx context.Context, opts *CmdUnpack) error {
 	is, err := imageset.ParseFile(opts.Args.ImageSetPath)
 	if err != nil {
 		return fmt.Errorf("read imageset: %w", err)
 	}
 
-	atlas, err := edds.Read(opts.Args.EDDSPath)
+	var renames []spriteRename
+	if opts.RenameMap != "" {
+		if renames, err = readRenameMap(opts.RenameMap); err != nil {
+			return err
+		}
+	}
+
+	var rotated map[image.Point]bool
+	if opts.Layout != "" {
+		manifest, err := readLayoutManifest(opts.Layout)
+		if err != nil {
+			return err
+		}
+		rotated = rotatedPositions(manifest)
+	}
+
+	atlas, err := edds.DecodeFile(opts.Args.EDDSPath)
 	if err != nil {
 		return fmt.Errorf("read edds: %w", err)
 	}
@@ -71,6 +95,9 @@ func runUnpack(opts *CmdUnpack) error {
 	if outDir == "" {
 		outDir = "."
 	}
+	if err := checkWritable(ctx, outDir); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(outDir, 0750); err != nil {
 		return fmt.Errorf("mkdir: %w", err)
 	}
@@ -81,13 +108,16 @@ func runUnpack(opts *CmdUnpack) error {
 	}
 
 	// root images
-	rootImages := is.Images
+	rootImages := applyRenames(is.Images, renames)
 	if opts.Dedup {
 		rootImages = deduplicateDefs(rootImages)
 	}
 	if len(rootImages) > 0 {
 		for _, def := range rootImages {
-			if err := writeOne(atlas, def, sx, sy, outDir, "", format, opts.Overwrite); err != nil {
+			if err := checkInterrupted(ctx); err != nil {
+				return err
+			}
+			if err := writeOne(atlas, def, rotated[image.Pt(def.Pos.X, def.Pos.Y)], sx, sy, outDir, "", format, opts.Overwrite); err != nil {
 				return err
 			}
 		}
@@ -95,7 +125,7 @@ func runUnpack(opts *CmdUnpack) error {
 
 	// groups
 	for _, g := range is.Groups {
-		groupImages := g.Images
+		groupImages := applyRenames(g.Images, renames)
 		if opts.Dedup {
 			groupImages = deduplicateDefs(groupImages)
 		}
@@ -104,7 +134,10 @@ func runUnpack(opts *CmdUnpack) error {
 			groupDir = sanitizeName(g.Name)
 		}
 		for _, def := range groupImages {
-			if err := writeOne(atlas, def, sx, sy, outDir, groupDir, format, opts.Overwrite); err != nil {
+			if err := checkInterrupted(ctx); err != nil {
+				return err
+			}
+			if err := writeOne(atlas, def, rotated[image.Pt(def.Pos.X, def.Pos.Y)], sx, sy, outDir, groupDir, format, opts.Overwrite); err != nil {
 				return err
 			}
 		}
@@ -113,12 +146,20 @@ func runUnpack(opts *CmdUnpack) error {
 	return nil
 }
 
-// writeOne writes a single image to the output directory.
-func writeOne(atlas image.Image, def imageset.Image, sx, sy int, baseDir, groupDir, format string, overwrite bool) error {
-	sub, err := crop(atlas, def.Pos.X*sx, def.Pos.Y*sy, def.Size.Width*sx, def.Size.Height*sy)
+// writeOne writes a single image to the output directory. A rotated image
+// covers Size.Height x Size.Width atlas pixels and is turned back upright.
+func writeOne(atlas image.Image, def imageset.Image, rotated bool, sx, sy int, baseDir, groupDir, format string, overwrite bool) error {
+	w, h := def.Size.Width*sx, def.Size.Height*sy
+	if rotated {
+		w, h = def.Size.Height*sx, def.Size.Width*sy
+	}
+	sub, err := crop(atlas, def.Pos.X*sx, def.Pos.Y*sy, w, h)
 	if err != nil {
 		return fmt.Errorf("crop %q: %w", def.Name, err)
 	}
+	if rotated {
+		sub = unrotate(sub)
+	}
 
 	dir := baseDir
 	if groupDir != "" {
@@ -128,7 +169,7 @@ func writeOne(atlas image.Image, def imageset.Image, sx, sy int, baseDir, groupD
 		}
 	}
 
-	outPath := filepath.Join(dir, def.Name+"."+format)
+	outPath := filepath.Join(dir, separatorReplacer.Replace(def.Name)+"."+format)
 	if !overwrite {
 		if _, err := os.Stat(outPath); err == nil {
 			return fmt.Errorf("output file %q exists (use --force)", outPath)
@@ -168,10 +209,24 @@ func crop(src image.Image, x, y, w, h int) (*image.RGBA, error) {
 	return dst, nil
 }
 
+// unrotate turns a sprite stored 90 degrees clockwise back upright.
+func unrotate(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := range b.Dx() {
+		for x := range b.Dy() {
+			dst.SetRGBA(x, y, src.RGBAAt(b.Max.X-1-y, b.Min.Y+x))
+		}
+	}
+
+	return dst
+}
+
 // sanitizeName sanitizes the name of the group.
 func sanitizeName(s string) string {
 	s = strings.TrimSpace(s)
 	s = strings.ReplaceAll(s, " ", "_")
+	s = separatorReplacer.Replace(s)
 	s = strings.ReplaceAll(s, "..", ".")
 	if s == "" {
 		return "group"
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CmdVerify checks that committed outputs of build projects match their
+// sources without packing.
+type CmdVerify struct {
+	Args struct {
+		Path string `positional-arg-name:"path" description:"Path to config file or directory (default: ./.imageset-packer.yaml)"`
+	} `positional-args:"yes"`
+
+	CacheDir string   `long:"cache-dir" description:"Directory with .imagehash cache files (overrides project cache_dir)"`
+	Only     []string `short:"p" long:"project" description:"Verify only selected project names (repeatable)"`
+	NoMtime  bool     `long:"no-mtime" description:"Do not flag outputs older than their sources when a project has no cache"`
+}
+
+// Execute runs the verify command.
+func (c *CmdVerify) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the verify command until ctx is canceled.
+func (c *CmdVerify) ExecuteContext(ctx context.Context, args []string) error {
+	return runVerify(ctx, c)
+}
+
+func runVerify(ctx context.Context, opts *CmdVerify) error {
+	selected, err := loadProjects(opts.Args.Path, opts.Only, opts.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	stale := 0
+	for i := range selected {
+		if err := checkInterrupted(ctx); err != nil {
+			return err
+		}
+
+		cfg := &selected[i]
+		name, err := resolveProjectName(cfg)
+		if err != nil {
+			return err
+		}
+		reasons, err := verifyProject(ctx, cfg, !opts.NoMtime)
+		if err != nil {
+			return fmt.Errorf("verify %s: %w", name, err)
+		}
+		if len(reasons) == 0 {
+			fmt.Printf("ok     %s\n", name)
+			continue
+		}
+
+		stale++
+		fmt.Printf("stale  %s\n", name)
+		for _, reason := range reasons {
+			fmt.Printf("       %s\n", reason)
+		}
+	}
+
+	if stale > 0 {
+		return fmt.Errorf("%d of %d project(s) stale; run build to update them", stale, len(selected))
+	}
+
+	return nil
+}
+
+// verifyProject returns why outputs of a project do not match its sources;
+// none means up to date. With a cache the recorded inputs, settings and
+// output hashes are compared, naming changed input files when the cache
+// lists them. Without a cache, and with checkMtime, outputs older than any
+// input are flagged instead; content hashes are preferred when present
+// because checkouts reset mtimes.
+func verifyProject(ctx context.Context, cfg *CmdPack, checkMtime bool) ([]string, error) {
+	outputs, err := resolvePackOutputs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cachePath, err := resolveCachePath(cfg.Cache, outputs.Dir, outputs.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var reasons []string
+	var outputTimes []time.Time
+	for _, path := range []string{outputs.Imageset, outputs.Atlas} {
+		info, err := os.Stat(path)
+		if err != nil {
+			reasons = append(reasons, "missing output "+filepath.Base(path))
+			continue
+		}
+		outputTimes = append(outputTimes, info.ModTime())
+	}
+	if len(reasons) > 0 {
+		return reasons, nil
+	}
+
+	files, err := discoverImageFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := readCache(cachePath)
+	if err != nil {
+		reasons = append(reasons, fmt.Sprintf("cache %s: %v", filepath.Base(cachePath), err))
+	}
+	if prev != nil {
+		inputsHash, inputs, err := computeInputsHash(ctx, cfg, files, nil)
+		if err != nil {
+			return nil, err
+		}
+		if prev.InputsHash != inputsHash {
+			reasons = append(reasons, "inputs changed since the last pack"+changedInputs(prev.Files, inputs))
+		}
+		if !prev.Legacy {
+			settingsHash, err := computeSettingsHash(cfg)
+			if err != nil {
+				return nil, err
+			}
+			if prev.SettingsHash != settingsHash {
+				reasons = append(reasons, "settings changed since the last pack")
+			}
+			if !outputsMatch(prev.Outputs, outputs.Dir) {
+				reasons = append(reasons, "outputs modified after the last pack")
+			}
+		}
+	}
+
+	if prev == nil && checkMtime {
+		oldest := outputTimes[0]
+		for _, t := range outputTimes[1:] {
+			if t.Before(oldest) {
+				oldest = t
+			}
+		}
+
+		var newer []string
+		for _, f := range files {
+			if f.modTime.After(oldest) {
+				rel, err := filepath.Rel(cfg.Args.Input, f.path)
+				if err != nil {
+					rel = f.path
+				}
+				newer = append(newer, filepath.ToSlash(rel))
+			}
+		}
+		if len(newer) > 0 {
+			reasons = append(reasons, fmt.Sprintf("outputs older than %d source(s): %s", len(newer), listNames(newer)))
+		}
+	}
+
+	return reasons, nil
+}
+
+// changedInputs lists input files added, removed or modified since the
+// cache was written, as a suffix for the stale reason. Caches without
+// input records yield "".
+func changedInputs(recorded, current []cacheFile) string {
+	if len(recorded) == 0 {
+		return ""
+	}
+
+	prev := make(map[string]uint64, len(recorded))
+	for _, f := range recorded {
+		prev[f.Path] = f.Hash
+	}
+
+	var changed []string
+	for _, f := range current {
+		hash, ok := prev[f.Path]
+		switch {
+		case !ok:
+			changed = append(changed, f.Path+" (added)")
+		case hash != f.Hash:
+			changed = append(changed, f.Path+" (modified)")
+		}
+		delete(prev, f.Path)
+	}
+	for path := range prev {
+		changed = append(changed, path+" (removed)")
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	return ": " + listNames(changed)
+}
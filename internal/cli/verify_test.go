@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangedInputs(t *testing.T) {
+	t.Parallel()
+
+	recorded := []cacheFile{{Path: "a.png", Hash: 1}, {Path: "b.png", Hash: 2}, {Path: "c.png", Hash: 3}}
+	current := []cacheFile{{Path: "a.png", Hash: 1}, {Path: "b.png", Hash: 20}, {Path: "d.png", Hash: 4}}
+
+	got := changedInputs(recorded, current)
+	want := ": b.png (modified), c.png (removed), d.png (added)"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := changedInputs(nil, current); got != "" {
+		t.Fatalf("without records got %q, want empty", got)
+	}
+}
+
+func TestVerifyProjectMtime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "icons")
+	if err := os.MkdirAll(input, 0750); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(input, "icon.png"), "png")
+
+	cfg := &CmdPack{Name: "icons"}
+	cfg.Args.Input = input
+	cfg.Args.Output = dir
+	reasons, err := verifyProject(context.Background(), cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reasons) != 2 || !strings.HasPrefix(reasons[0], "missing output") {
+		t.Fatalf("got %q, want missing outputs", reasons)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"icons.imageset", "icons.edds"} {
+		path := filepath.Join(dir, name)
+		writeTestFile(t, path, "out")
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reasons, err = verifyProject(context.Background(), cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "older than 1 source(s): icon.png") {
+		t.Fatalf("got %q, want outputs older than icon.png", reasons)
+	}
+
+	if reasons, err = verifyProject(context.Background(), cfg, false); err != nil || len(reasons) != 0 {
+		t.Fatalf("without mtime check got %q, %v", reasons, err)
+	}
+}
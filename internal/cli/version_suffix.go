@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitVersionSuffix is the --version-suffix value resolved by git describe.
+const gitVersionSuffix = "git"
+
+// resolveVersionSuffix returns the suffix appended to output names. The
+// value "git" runs git describe in dir; other values are used as given.
+// The suffix leaves out --dirty: outputs written into the work tree would
+// otherwise rename the next run's outputs and orphan the previous ones.
+func resolveVersionSuffix(value, dir string) (string, error) {
+	suffix := strings.TrimSpace(value)
+	if suffix == gitVersionSuffix {
+		cmd := exec.Command("git", "-C", dir, "describe", "--tags", "--always")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("git describe in %s: %s", dir, msg)
+			}
+			return "", fmt.Errorf("git describe in %s: %w", dir, err)
+		}
+		suffix = strings.TrimSpace(string(out))
+	}
+
+	for _, r := range suffix {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '.' && r != '-' && r != '_' {
+			return "", fmt.Errorf("invalid --version-suffix %q: only letters, digits, '.', '-' and '_' are allowed", suffix)
+		}
+	}
+
+	return suffix, nil
+}
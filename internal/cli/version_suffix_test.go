@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePackOutputsVersionSuffix(t *testing.T) {
+	opts := &CmdPack{Name: "icons", VersionSuffix: "v2-test"}
+	opts.Args.Input = t.TempDir()
+
+	outputs, err := resolvePackOutputs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs.Name != "icons_v2-test" || filepath.Base(outputs.EDDS) != "icons_v2-test.edds" {
+		t.Fatalf("got name %q, edds %q", outputs.Name, outputs.EDDS)
+	}
+	if got := formatEddsRefPath("mod/data", outputs.Name); got != "mod/data/icons_v2-test.edds" {
+		t.Fatalf("texture path = %q", got)
+	}
+
+	opts.VersionSuffix = "../v2"
+	if _, err := resolvePackOutputs(opts); err == nil {
+		t.Fatal("expected error for suffix with path separators")
+	}
+}
+
+func TestResolveVersionSuffixGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "init")
+	git("tag", "v1.2.0")
+
+	suffix, err := resolveVersionSuffix("git", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suffix != "v1.2.0" {
+		t.Fatalf("suffix = %q, want v1.2.0", suffix)
+	}
+
+	if _, err := resolveVersionSuffix("git", t.TempDir()); err == nil {
+		t.Fatal("expected error outside a git repository")
+	}
+}
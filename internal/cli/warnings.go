@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Warning categories recorded in --warnings-json.
+const (
+	warnIORetry       = "io-retry"
+	warnSkippedFile   = "skipped-file"
+	warnGapBleeding   = "gap-bleeding"
+	warnAutoAdjusted  = "auto-adjusted"
+	warnMixedSources  = "mixed-sources"
+	warnFrozenResized = "frozen-resized"
+	warnPlacementDump = "placement-dump"
+	warnRenameMap     = "rename-map"
+	warnNameCollision = "name-collision"
+	warnGroupOverride = "group-override"
+)
+
+// warningRecord is one warning in --warnings-json.
+type warningRecord struct {
+	Project  string `json:"project,omitempty"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+	Path     string `json:"path,omitempty"`
+}
+
+// warningLog prints warnings to stderr and keeps them for --warnings-json.
+// A nil *warningLog only prints.
+type warningLog struct {
+	records []warningRecord
+	mu      sync.Mutex
+}
+
+// Warn prints a warning and records it under category. path names the
+// file the warning is about, if any.
+func (l *warningLog) Warn(project, category, path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, warningRecord{Project: project, Category: category, Message: msg, Path: path})
+}
+
+// Records returns a copy of the recorded warnings.
+func (l *warningLog) Records() []warningRecord {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]warningRecord(nil), l.records...)
+}
+
+// WriteJSON writes recorded warnings to path as {"warnings": [...]}. The
+// file is written also without warnings, so CI can rely on it.
+func (l *warningLog) WriteJSON(path string) error {
+	records := l.Records()
+	if records == nil {
+		records = []warningRecord{}
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Warnings []warningRecord `json:"warnings"`
+	}{records}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// warn prints a warning and records it in the log of this pack run.
+func (c *CmdPack) warn(category, path, format string, args ...any) {
+	project := c.Name
+	if project == "" {
+		project = c.Args.Input
+	}
+
+	c.warnings.Warn(project, category, path, format, args...)
+}
+
+// retryPolicy returns the IO retry policy with retries reported as warnings.
+func (c *CmdPack) retryPolicy() retryPolicy {
+	p := newRetryPolicy(&c.IO)
+	p.Warn = func(format string, args ...any) {
+		c.warn(warnIORetry, "", format, args...)
+	}
+
+	return p
+}
+
+// recordedWarnings returns warnings of the run so far.
+func (c *CmdPack) recordedWarnings() []warningRecord {
+	return c.warnings.Records()
+}
+
+// recordedWarnings returns warnings of all projects built so far.
+func (c *CmdBuild) recordedWarnings() []warningRecord {
+	return c.warnings.Records()
+}
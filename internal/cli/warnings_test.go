@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarningLogWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "warnings.json")
+	opts := &CmdPack{Name: "ui", warnings: &warningLog{}}
+	opts.warn(warnSkippedFile, "a.tga", "skipped %s", "a.tga")
+
+	calls := 0
+	retry := opts.retryPolicy()
+	retry.Retries = 1
+	_ = retry.Do(context.Background(), "read b.png", func() error {
+		calls++
+		if calls == 1 {
+			return &fs.PathError{Op: "read", Path: "b.png", Err: transientErrnos[0]}
+		}
+		return nil
+	})
+
+	if err := opts.warnings.WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Warnings []warningRecord `json:"warnings"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	want := []warningRecord{
+		{Project: "ui", Category: warnSkippedFile, Message: "skipped a.tga", Path: "a.tga"},
+		{Project: "ui", Category: warnIORetry},
+	}
+	if len(got.Warnings) != len(want) {
+		t.Fatalf("got %+v", got.Warnings)
+	}
+	for i, w := range want {
+		g := got.Warnings[i]
+		if g.Project != w.Project || g.Category != w.Category || (w.Message != "" && g.Message != w.Message) || g.Path != w.Path {
+			t.Fatalf("warning %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	var empty *warningLog
+	empty.Warn("", warnGapBleeding, "", "printed only")
+	if err := empty.WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON nil log: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{\n  \"warnings\": []\n}\n" {
+		t.Fatalf("empty log wrote %q", data)
+	}
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// projectWorkDir is an isolated per-project directory for intermediate files.
+// Outputs are staged inside it and moved into place only after every file was
+// written, so concurrent projects never share temp names and readers never see
+// half-written atlases.
+type projectWorkDir struct {
+	dir string
+}
+
+// newProjectWorkDir creates a unique staging directory inside outputDir.
+// Staying on the same filesystem keeps the final move an atomic rename.
+func newProjectWorkDir(outputDir, name string) (*projectWorkDir, error) {
+	dir, err := os.MkdirTemp(outputDir, "."+name+"-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	return &projectWorkDir{dir: dir}, nil
+}
+
+// Path returns the staging path for a final output path.
+func (w *projectWorkDir) Path(finalPath string) string {
+	return filepath.Join(w.dir, filepath.Base(finalPath))
+}
+
+// Commit moves staged files to their final paths.
+func (w *projectWorkDir) Commit(finalPaths ...string) error {
+	for _, finalPath := range finalPaths {
+		if err := os.Rename(w.Path(finalPath), finalPath); err != nil {
+			return fmt.Errorf("failed to move %q into place: %w", finalPath, err)
+		}
+	}
+
+	return nil
+}
+
+// Close removes the staging directory with anything left in it.
+func (w *projectWorkDir) Close() error {
+	return os.RemoveAll(w.dir)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/woozymasta/bcn"
+
+	"github.com/woozymasta/imageset-packer/edds"
 )
 
 // EncodeSettings configures DDS/EDDS output encoding.
@@ -13,8 +15,17 @@ type EncodeSettings struct {
 	Format bcn.Format
 	// Quality controls BCn quality: 0 = library default, 1..10 = explicit levels.
 	Quality int
+	// Compression picks EDDS block compression per mip level. Nil means
+	// edds.DefaultStrategy.
+	Compression edds.Strategy
 	// Mipmaps limits written mip levels for EDDS: 0 = full chain, 1 = base only.
 	Mipmaps int
+	// MipCap caps the EDDS full chain (Mipmaps 0): 0 = edds.DefaultMipCap,
+	// edds.NoMipCap = down to 1x1.
+	MipCap int
+	// MinMipSize ends the EDDS mip chain before a level with a side below
+	// it: 0 = down to 1x1.
+	MinMipSize int
 }
 
 // ParseOutputFormat parses a textual output format alias.
@@ -31,9 +42,31 @@ func ParseOutputFormat(s string) (bcn.Format, error) {
 		return bcn.FormatDXT1, nil
 	case "dxt5", "bc3":
 		return bcn.FormatDXT5, nil
+	case "bc5", "ati2":
+		return bcn.FormatBC5, nil
 	default:
 		return bcn.FormatUnknown, fmt.Errorf(
-			"unknown format %q (supported: bgra8, dxt1, dxt5)",
+			"unknown format %q (supported: bgra8, dxt1, dxt5, bc5)",
+			s,
+		)
+	}
+}
+
+// ParseCompression parses an EDDS compression mode. Every mode except
+// "none" stores mips under 1 KiB as COPY blocks.
+func ParseCompression(s string) (edds.Strategy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "hc":
+		return edds.DefaultStrategy, nil
+	case "balanced":
+		return edds.Tiered(1024, edds.LZ4HCCompressor, edds.LZ4Compressor), nil
+	case "fast":
+		return edds.Tiered(1024, edds.LZ4Compressor, edds.LZ4Compressor), nil
+	case "none":
+		return edds.Uniform(edds.CopyCompressor), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown compression %q (supported: hc, balanced, fast, none)",
 			s,
 		)
 	}
@@ -73,6 +106,9 @@ func effectiveEncodeSettings(opts *EncodeSettings) EncodeSettings {
 	}
 	e.Quality = opts.Quality
 	e.Mipmaps = opts.Mipmaps
+	e.Compression = opts.Compression
+	e.MinMipSize = opts.MinMipSize
+	e.MipCap = opts.MipCap
 
 	return e
 }
@@ -19,6 +19,8 @@ func TestParseOutputFormat(t *testing.T) {
 		{name: "dxgi-alias", input: "DXGI_FORMAT_B8G8R8A8_UNORM", want: bcn.FormatBGRA8},
 		{name: "dxt1", input: "dxt1", want: bcn.FormatDXT1},
 		{name: "bc3", input: "bc3", want: bcn.FormatDXT5},
+		{name: "bc5", input: "bc5", want: bcn.FormatBC5},
+		{name: "ati2", input: "ATI2", want: bcn.FormatBC5},
 	}
 
 	for _, tc := range tests {
@@ -63,3 +65,16 @@ func TestValidateQualityLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCompression(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "hc", "Balanced", "fast", "none"} {
+		if strategy, err := ParseCompression(s); err != nil || strategy == nil {
+			t.Fatalf("ParseCompression(%q) = %v, %v", s, strategy, err)
+		}
+	}
+	if _, err := ParseCompression("zstd"); err == nil {
+		t.Fatal("expected error for unknown compression")
+	}
+}
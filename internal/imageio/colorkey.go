@@ -1,22 +1,201 @@
 package imageio
 
 import (
+	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 )
 
+// ColorKeyMode selects what happens to pixels matching the color key.
+type ColorKeyMode string
+
+const (
+	// ColorKeyTransparent makes key pixels fully transparent.
+	ColorKeyTransparent ColorKeyMode = "transparent"
+	// ColorKeyReplace paints key pixels with ColorKeyOptions.Replacement.
+	ColorKeyReplace ColorKeyMode = "replace"
+	// ColorKeyNeighbors paints key pixels with the average color of the
+	// nearest pixels that are neither key nor transparent, growing inward
+	// from the edges of keyed areas.
+	ColorKeyNeighbors ColorKeyMode = "neighbors"
+)
+
+// ColorKeyOptions controls ApplyColorKeyWithOptions.
+type ColorKeyOptions struct {
+	// Mode is the key processing mode. Empty means ColorKeyTransparent.
+	Mode ColorKeyMode
+	// Replacement is the color painted by ColorKeyReplace.
+	Replacement RGB
+}
+
+// ParseColorKeyMode parses a textual color key mode; empty means
+// ColorKeyTransparent.
+func ParseColorKeyMode(s string) (ColorKeyMode, error) {
+	switch mode := ColorKeyMode(s); mode {
+	case "":
+		return ColorKeyTransparent, nil
+	case ColorKeyTransparent, ColorKeyReplace, ColorKeyNeighbors:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown color key mode %q (transparent, replace, neighbors)", s)
+	}
+}
+
+// DetectColorKey returns the dominant color of the border pixels of img as
+// a color key. It finds none when fewer than half of the border pixels are
+// opaque pixels of one color, e.g. for images with a real alpha channel or
+// without a flat background.
+func DetectColorKey(img image.Image) (RGB, bool) {
+	b := img.Bounds()
+	if b.Empty() {
+		return RGB{}, false
+	}
+
+	counts := make(map[RGB]int)
+	total := 0
+	sample := func(x, y int) {
+		total++
+		c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		if c.A == 0xff {
+			counts[RGB{R: c.R, G: c.G, B: c.B}]++
+		}
+	}
+	for x := b.Min.X; x < b.Max.X; x++ {
+		sample(x, b.Min.Y)
+		if b.Dy() > 1 {
+			sample(x, b.Max.Y-1)
+		}
+	}
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		sample(b.Min.X, y)
+		if b.Dx() > 1 {
+			sample(b.Max.X-1, y)
+		}
+	}
+
+	var key RGB
+	best := 0
+	for c, n := range counts {
+		if n > best || n == best && c.String() < key.String() {
+			key, best = c, n
+		}
+	}
+
+	return key, best*2 >= total
+}
+
 // ApplyColorKey makes all pixels matching the RGB key fully transparent.
 func ApplyColorKey(img image.Image, key RGB) image.Image {
+	return ApplyColorKeyWithOptions(img, key, nil)
+}
+
+// ApplyColorKeyWithOptions processes all pixels matching the RGB key as
+// selected by opts. Nil opts make them fully transparent. Replaced pixels
+// keep their alpha.
+func ApplyColorKeyWithOptions(img image.Image, key RGB, opts *ColorKeyOptions) image.Image {
+	mode := ColorKeyTransparent
+	if opts != nil && opts.Mode != "" {
+		mode = opts.Mode
+	}
+
 	b := img.Bounds()
 	rgba := image.NewRGBA(b)
 	draw.Draw(rgba, b, img, b.Min, draw.Src)
 
 	p := rgba.Pix
+	keyed := make([]bool, len(p)/4)
 	for i := 0; i+3 < len(p); i += 4 {
-		if p[i] == key.R && p[i+1] == key.G && p[i+2] == key.B {
+		if p[i] != key.R || p[i+1] != key.G || p[i+2] != key.B {
+			continue
+		}
+
+		switch mode {
+		case ColorKeyReplace:
+			p[i], p[i+1], p[i+2] = opts.Replacement.R, opts.Replacement.G, opts.Replacement.B
+		case ColorKeyNeighbors:
+			keyed[i/4] = true
+		default:
 			p[i+3] = 0
 		}
 	}
+	if mode == ColorKeyNeighbors {
+		fillFromNeighbors(rgba, keyed)
+	}
 
 	return rgba
 }
+
+// fillFromNeighbors paints keyed pixels of img ring by ring with the
+// average color of their already painted or original 8-neighbors. Keyed
+// areas out of reach of any visible pixel keep the key color.
+func fillFromNeighbors(img *image.RGBA, keyed []bool) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	p := img.Pix
+
+	// source marks pixels whose color may be sampled.
+	source := make([]bool, len(keyed))
+	for i := range keyed {
+		source[i] = !keyed[i] && p[i*4+3] > 0
+	}
+	queued := make([]bool, len(keyed))
+
+	neighbors := func(i int, fn func(n int)) {
+		x, y := i%w, i/w
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if (dx != 0 || dy != 0) && nx >= 0 && ny >= 0 && nx < w && ny < h {
+					fn(ny*w + nx)
+				}
+			}
+		}
+	}
+
+	var ring []int
+	for i, k := range keyed {
+		if !k {
+			continue
+		}
+		neighbors(i, func(n int) {
+			if source[n] && !queued[i] {
+				queued[i] = true
+				ring = append(ring, i)
+			}
+		})
+	}
+
+	for len(ring) > 0 {
+		colors := make([][3]uint8, len(ring))
+		for j, i := range ring {
+			var sum [3]int
+			count := 0
+			neighbors(i, func(n int) {
+				if source[n] {
+					sum[0] += int(p[n*4])
+					sum[1] += int(p[n*4+1])
+					sum[2] += int(p[n*4+2])
+					count++
+				}
+			})
+			for c := range colors[j] {
+				colors[j][c] = uint8(sum[c] / count) //nolint:gosec // Average of 8-bit values.
+			}
+		}
+
+		var next []int
+		for j, i := range ring {
+			p[i*4], p[i*4+1], p[i*4+2] = colors[j][0], colors[j][1], colors[j][2]
+			source[i] = true
+		}
+		for _, i := range ring {
+			neighbors(i, func(n int) {
+				if keyed[n] && !queued[n] {
+					queued[n] = true
+					next = append(next, n)
+				}
+			})
+		}
+		ring = next
+	}
+}
@@ -0,0 +1,125 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyColorKeyWithOptions(t *testing.T) {
+	t.Parallel()
+
+	// A 4x1 row: red, magenta, magenta, blue.
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	key := RGB{R: 0xff, B: 0xff}
+	src.SetNRGBA(0, 0, color.NRGBA{R: 200, A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 0xff, B: 0xff, A: 255})
+	src.SetNRGBA(2, 0, color.NRGBA{R: 0xff, B: 0xff, A: 255})
+	src.SetNRGBA(3, 0, color.NRGBA{B: 100, A: 255})
+
+	tests := []struct {
+		name string
+		opts *ColorKeyOptions
+		want [4]color.RGBA
+	}{
+		{
+			name: "transparent",
+			want: [4]color.RGBA{{R: 200, A: 255}, {R: 0xff, B: 0xff}, {R: 0xff, B: 0xff}, {B: 100, A: 255}},
+		},
+		{
+			name: "replace",
+			opts: &ColorKeyOptions{Mode: ColorKeyReplace, Replacement: RGB{R: 30, G: 30, B: 30}},
+			want: [4]color.RGBA{{R: 200, A: 255}, {R: 30, G: 30, B: 30, A: 255}, {R: 30, G: 30, B: 30, A: 255}, {B: 100, A: 255}},
+		},
+		{
+			name: "neighbors",
+			opts: &ColorKeyOptions{Mode: ColorKeyNeighbors},
+			want: [4]color.RGBA{{R: 200, A: 255}, {R: 200, A: 255}, {B: 100, A: 255}, {B: 100, A: 255}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ApplyColorKeyWithOptions(src, key, tt.opts).(*image.RGBA)
+			for x, want := range tt.want {
+				if c := got.RGBAAt(x, 0); c != want {
+					t.Fatalf("pixel %d = %v, want %v", x, c, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyColorKeyNeighborsFillsInward(t *testing.T) {
+	t.Parallel()
+
+	// A 5x5 magenta square framed by gray: the center is two rings away
+	// from the frame and still gets the frame color.
+	src := image.NewRGBA(image.Rect(0, 0, 7, 7))
+	for y := range 7 {
+		for x := range 7 {
+			c := color.RGBA{R: 0xff, B: 0xff, A: 255}
+			if x == 0 || y == 0 || x == 6 || y == 6 {
+				c = color.RGBA{R: 90, G: 90, B: 90, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	got := ApplyColorKeyWithOptions(src, RGB{R: 0xff, B: 0xff}, &ColorKeyOptions{Mode: ColorKeyNeighbors}).(*image.RGBA)
+	if c := got.RGBAAt(3, 3); c != (color.RGBA{R: 90, G: 90, B: 90, A: 255}) {
+		t.Fatalf("center = %v, want frame color", c)
+	}
+
+	// Without any visible pixel the key color stays.
+	solid := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < len(solid.Pix); i += 4 {
+		solid.Pix[i], solid.Pix[i+2], solid.Pix[i+3] = 0xff, 0xff, 0xff
+	}
+	got = ApplyColorKeyWithOptions(solid, RGB{R: 0xff, B: 0xff}, &ColorKeyOptions{Mode: ColorKeyNeighbors}).(*image.RGBA)
+	if c := got.RGBAAt(1, 1); c != (color.RGBA{R: 0xff, B: 0xff, A: 255}) {
+		t.Fatalf("solid key pixel = %v, want unchanged", c)
+	}
+}
+
+func TestParseColorKeyMode(t *testing.T) {
+	t.Parallel()
+
+	if m, err := ParseColorKeyMode(""); err != nil || m != ColorKeyTransparent {
+		t.Fatalf(`ParseColorKeyMode("") = %q, %v`, m, err)
+	}
+	if m, err := ParseColorKeyMode("neighbors"); err != nil || m != ColorKeyNeighbors {
+		t.Fatalf(`ParseColorKeyMode("neighbors") = %q, %v`, m, err)
+	}
+	if _, err := ParseColorKeyMode("blur"); err == nil {
+		t.Fatal(`ParseColorKeyMode("blur") succeeded`)
+	}
+}
+
+func TestDetectColorKey(t *testing.T) {
+	t.Parallel()
+
+	bg := color.NRGBA{G: 0xff, A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	for y := range 6 {
+		for x := range 8 {
+			img.SetNRGBA(x, y, bg)
+		}
+	}
+	// A sprite touching the border on one side still leaves the background
+	// dominant.
+	for y := 1; y < 6; y++ {
+		img.SetNRGBA(3, y, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	}
+	if key, ok := DetectColorKey(img); !ok || key != (RGB{G: 0xff}) {
+		t.Fatalf("DetectColorKey = %v, %v, want 00ff00", key, ok)
+	}
+
+	// A transparent border is no key.
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	transparent.SetNRGBA(1, 1, color.NRGBA{R: 0xff, A: 255})
+	if key, ok := DetectColorKey(transparent); ok {
+		t.Fatalf("DetectColorKey of transparent border = %v", key)
+	}
+}
@@ -9,6 +9,11 @@ import (
 // RGB stores an 8-bit per channel color.
 type RGB struct{ R, G, B uint8 }
 
+// String returns the color as 6 hex digits, the form ParseHexRGB reads.
+func (c RGB) String() string {
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
 // ParseHexRGB parses a 6-digit hex RGB string (with or without leading '#').
 func ParseHexRGB(s string) (RGB, error) {
 	s = strings.TrimSpace(strings.ToLower(s))
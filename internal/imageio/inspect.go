@@ -0,0 +1,138 @@
+package imageio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AlphaMode tells how a decoded source stores alpha.
+type AlphaMode string
+
+const (
+	// AlphaNone marks fully opaque sources.
+	AlphaNone AlphaMode = "opaque"
+	// AlphaStraight marks color stored independent of alpha.
+	AlphaStraight AlphaMode = "straight"
+	// AlphaPremultiplied marks color stored multiplied by alpha.
+	AlphaPremultiplied AlphaMode = "premultiplied"
+)
+
+// Color space tags reported by Inspect.
+const (
+	ColorTagSRGB     = "sRGB"
+	ColorTagICC      = "ICC"
+	ColorTagGamma    = "gamma"
+	ColorTagUntagged = "untagged"
+)
+
+// SourceInfo describes source properties that make images look different
+// once packed side by side.
+type SourceInfo struct {
+	// ColorTag is the color space tag of the file, e.g. ColorTagSRGB.
+	ColorTag string
+	// Alpha is how the decoded image stores alpha.
+	Alpha AlphaMode
+	// BitDepth is 8 or 16 bits per channel.
+	BitDepth int
+}
+
+// pngSignature starts every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Inspect reports bit depth and alpha mode of a decoded image and the
+// color space tag of its file. Only PNG files carry tags; other formats
+// are reported as untagged.
+func Inspect(path string, img image.Image) (SourceInfo, error) {
+	info := SourceInfo{ColorTag: ColorTagUntagged, BitDepth: 8, Alpha: alphaMode(img)}
+	switch img.ColorModel() {
+	case color.RGBA64Model, color.NRGBA64Model, color.Gray16Model, color.Alpha16Model:
+		info.BitDepth = 16
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		f, err := os.Open(path)
+		if err != nil {
+			return info, err
+		}
+		defer func() { _ = f.Close() }()
+
+		tag, err := pngColorTag(f)
+		if err != nil {
+			return info, err
+		}
+		info.ColorTag = tag
+	}
+
+	return info, nil
+}
+
+// alphaMode returns how img stores alpha.
+func alphaMode(img image.Image) AlphaMode {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return AlphaNone
+	}
+
+	model := img.ColorModel()
+	if p, ok := model.(color.Palette); ok && len(p) > 0 {
+		switch p[0].(type) {
+		case color.NRGBA, color.NRGBA64:
+			return AlphaStraight
+		default:
+			return AlphaPremultiplied
+		}
+	}
+	switch model {
+	case color.RGBAModel, color.RGBA64Model:
+		return AlphaPremultiplied
+	case color.GrayModel, color.Gray16Model, color.YCbCrModel, color.CMYKModel:
+		return AlphaNone
+	default:
+		return AlphaStraight
+	}
+}
+
+// pngColorTag scans PNG chunks up to the image data for sRGB, iCCP and gAMA.
+func pngColorTag(r io.Reader) (string, error) {
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return "", err
+	}
+	if !bytes.Equal(sig, pngSignature) {
+		return "", errors.New("not a PNG file")
+	}
+
+	tag := ColorTagUntagged
+	var head [8]byte
+	for {
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return tag, nil
+			}
+			return "", err
+		}
+
+		switch string(head[4:]) {
+		case "sRGB":
+			return ColorTagSRGB, nil
+		case "iCCP":
+			return ColorTagICC, nil
+		case "gAMA":
+			tag = ColorTagGamma
+		case "IDAT", "IEND":
+			return tag, nil
+		}
+
+		// Skip chunk data and CRC.
+		size := int64(binary.BigEndian.Uint32(head[:4])) + 4
+		if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package imageio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	translucent := image.NewNRGBA64(image.Rect(0, 0, 2, 2))
+	translucent.SetNRGBA64(0, 0, color.NRGBA64{R: 0xffff, A: 0x8000})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, translucent); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	plain := filepath.Join(dir, "plain.png")
+	if err := os.WriteFile(plain, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert an sRGB chunk right after IHDR (8 byte signature + 25 bytes).
+	data := buf.Bytes()
+	srgbChunk := []byte{0, 0, 0, 1, 's', 'R', 'G', 'B', 0, 0xae, 0xce, 0x1c, 0xe9}
+	tagged := filepath.Join(dir, "tagged.png")
+	if err := os.WriteFile(tagged, append(append(append([]byte(nil), data[:33]...), srgbChunk...), data[33:]...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		img  image.Image
+		path string
+		want SourceInfo
+	}{
+		{img: translucent, path: plain, want: SourceInfo{ColorTag: ColorTagUntagged, Alpha: AlphaStraight, BitDepth: 16}},
+		{img: translucent, path: tagged, want: SourceInfo{ColorTag: ColorTagSRGB, Alpha: AlphaStraight, BitDepth: 16}},
+		{img: image.NewRGBA(image.Rect(0, 0, 1, 1)), path: "x.tga", want: SourceInfo{ColorTag: ColorTagUntagged, Alpha: AlphaPremultiplied, BitDepth: 8}},
+		{img: image.NewGray(image.Rect(0, 0, 1, 1)), path: "x.bmp", want: SourceInfo{ColorTag: ColorTagUntagged, Alpha: AlphaNone, BitDepth: 8}},
+	}
+
+	for _, tt := range tests {
+		got, err := Inspect(tt.path, tt.img)
+		if err != nil {
+			t.Fatalf("Inspect(%s): %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Inspect(%s) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -13,15 +13,17 @@ import (
 	_ "golang.org/x/image/bmp"
 	_ "golang.org/x/image/tiff"
 
-	"github.com/woozymasta/edds"
+	"github.com/woozymasta/bcn"
 	"github.com/woozymasta/tga"
+
+	"github.com/woozymasta/imageset-packer/edds"
 )
 
 // Read loads an image from a supported file format.
 func Read(path string) (image.Image, error) {
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
 	switch ext {
-	case "png", "bmp", "tiff", "dds", "ktx":
+	case "png", "bmp", "tiff", "ktx":
 		f, err := os.Open(path)
 		if err != nil {
 			return nil, err
@@ -33,6 +35,20 @@ func Read(path string) (image.Image, error) {
 		}
 		return img, nil
 
+	// DDS is decoded directly: the bcn/dds registration matches a wrong
+	// magic, so image.Decode rejects every DDS file.
+	case "dds":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		_, img, err := bcn.DecodeDDS(f)
+		if err != nil {
+			return nil, err
+		}
+		return img, nil
+
 	case "tga":
 		f, err := os.Open(path)
 		if err != nil {
@@ -47,7 +63,7 @@ func Read(path string) (image.Image, error) {
 		return img, nil
 
 	case "edds":
-		return edds.Read(path)
+		return edds.DecodeFile(path)
 
 	default:
 		return nil, fmt.Errorf("unsupported input format: %q", ext)
@@ -58,7 +74,7 @@ func Read(path string) (image.Image, error) {
 func GetImageSize(path string) (width, height int, err error) {
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
 	switch ext {
-	case "png", "bmp", "tiff", "dds", "ktx":
+	case "png", "bmp", "tiff", "ktx":
 		f, err := os.Open(path)
 		if err != nil {
 			return 0, 0, err
@@ -71,6 +87,19 @@ func GetImageSize(path string) (width, height int, err error) {
 		}
 		return cfg.Width, cfg.Height, nil
 
+	case "dds":
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer func() { _ = f.Close() }()
+
+		h, err := bcn.ReadDDSHeader(f)
+		if err != nil {
+			return 0, 0, err
+		}
+		return int(h.Width), int(h.Height), nil
+
 	case "tga":
 		f, err := os.Open(path)
 		if err != nil {
@@ -85,7 +114,7 @@ func GetImageSize(path string) (width, height int, err error) {
 		return cfg.Width, cfg.Height, nil
 
 	case "edds":
-		cfg, err := edds.ReadConfig(path)
+		cfg, err := edds.DecodeConfigFile(path)
 		if err != nil {
 			return 0, 0, err
 		}
@@ -0,0 +1,77 @@
+package imageio
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/woozymasta/bcn"
+
+	"github.com/woozymasta/imageset-packer/edds"
+)
+
+// maxBCnMeanError is the largest mean per-channel error, on a 0..255
+// scale, accepted between a BCn mip and its source. Block compression
+// stays far below it; a broken container or swapped mips do not.
+const maxBCnMeanError = 12
+
+// VerifyEDDS re-reads the EDDS file at path and compares its base mip with
+// want. Lossless formats must match exactly, BCn formats within
+// maxBCnMeanError.
+func VerifyEDDS(path string, want image.Image, format bcn.Format) error {
+	got, err := edds.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("re-read %s: %w", path, err)
+	}
+	if format == bcn.FormatUnknown {
+		format = bcn.FormatBGRA8
+	}
+	if got.Format() != format {
+		return fmt.Errorf("%s: stored format %s, want %s", path, got.Format(), format)
+	}
+
+	wb, gb := want.Bounds(), got.Bounds()
+	if wb.Dx() != gb.Dx() || wb.Dy() != gb.Dy() {
+		return fmt.Errorf("%s: base mip is %dx%d, want %dx%d", path, gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+
+	// Lossless formats store straight alpha, so compare in that space.
+	// BCn compares premultiplied: colors hidden by zero alpha do not count.
+	// BC5 stores straight red and green only, e.g. X and Y of a normal map.
+	lossless := format == bcn.FormatBGRA8 || format == bcn.FormatRGBA8
+	straight, stored := lossless, 4
+	if format == bcn.FormatBC5 {
+		straight, stored = true, 2
+	}
+	var total uint64
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			w := channels(want.At(wb.Min.X+x, wb.Min.Y+y), straight)
+			g := channels(got.At(gb.Min.X+x, gb.Min.Y+y), straight)
+			if lossless && w != g {
+				return fmt.Errorf("%s: pixel (%d,%d) is %v, want %v", path, x, y, g, w)
+			}
+			for i := range stored {
+				total += uint64(max(w[i], g[i]) - min(w[i], g[i]))
+			}
+		}
+	}
+
+	mean := float64(total) / float64(wb.Dx()*wb.Dy()*stored)
+	if mean > maxBCnMeanError {
+		return fmt.Errorf("%s: mean %s error %.1f exceeds %d", path, format, mean, maxBCnMeanError)
+	}
+
+	return nil
+}
+
+// channels returns 8-bit RGBA channels of c, straight or premultiplied.
+func channels(c color.Color, straight bool) [4]uint8 {
+	if straight {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		return [4]uint8{n.R, n.G, n.B, n.A}
+	}
+
+	p := color.RGBAModel.Convert(c).(color.RGBA)
+	return [4]uint8{p.R, p.G, p.B, p.A}
+}
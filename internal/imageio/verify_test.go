@@ -0,0 +1,47 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyEDDS(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 8), B: 128, A: 255}) //nolint:gosec // bounded 0..255
+		}
+	}
+
+	for _, format := range []string{"bgra8", "dxt1", "dxt5", "bc5"} {
+		path := filepath.Join(t.TempDir(), format+".edds")
+		settings := &EncodeSettings{Format: mustParseFormat(t, format)}
+		if err := WriteWithOptions(path, img, settings); err != nil {
+			t.Fatalf("%s: WriteWithOptions: %v", format, err)
+		}
+		if err := VerifyEDDS(path, img, settings.Format); err != nil {
+			t.Fatalf("%s: VerifyEDDS: %v", format, err)
+		}
+
+		other := image.NewNRGBA(img.Rect)
+		if err := VerifyEDDS(path, other, settings.Format); err == nil {
+			t.Fatalf("%s: VerifyEDDS accepted a different image", format)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data[:len(data)-8], 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyEDDS(path, img, settings.Format); err == nil {
+			t.Fatalf("%s: VerifyEDDS accepted a truncated file", format)
+		}
+	}
+}
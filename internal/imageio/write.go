@@ -13,7 +13,7 @@ import (
 	"golang.org/x/image/bmp"
 	"golang.org/x/image/tiff"
 
-	"github.com/woozymasta/edds"
+	"github.com/woozymasta/imageset-packer/edds"
 )
 
 // Write saves an image to the given path based on its extension.
@@ -58,17 +58,11 @@ func WriteWithOptions(path string, img image.Image, opts *EncodeSettings) error
 		return tiff.Encode(f, img, &tiff.Options{Compression: tiff.Deflate})
 
 	case "dds":
-		cfg := effectiveEncodeSettings(opts)
-		if err := ValidateQualityLevel(cfg.Quality); err != nil {
+		wopts, err := eddsWriteOptions(opts)
+		if err != nil {
 			return err
 		}
-
-		encOpts := &bcn.EncodeOptions{
-			QualityLevel: cfg.Quality,
-			Workers:      0,
-		}
-
-		dds, err := bcn.EncodeDDSWithOptions([]image.Image{img}, cfg.Format, encOpts)
+		mips, err := edds.EncodeMipChain(img, wopts)
 		if err != nil {
 			return err
 		}
@@ -79,28 +73,46 @@ func WriteWithOptions(path string, img image.Image, opts *EncodeSettings) error
 		}
 		defer func() { _ = f.Close() }()
 
+		b := img.Bounds()
+		dds := &bcn.DDS{Format: wopts.Format, Width: b.Dx(), Height: b.Dy(), Faces: []bcn.Face{{Mipmaps: mips}}}
 		return dds.Write(f)
 
 	case "edds":
-		cfg := effectiveEncodeSettings(opts)
-		if cfg.Mipmaps < 0 {
-			return fmt.Errorf("mipmaps must be >= 0")
-		}
-		if err := ValidateQualityLevel(cfg.Quality); err != nil {
+		wopts, err := eddsWriteOptions(opts)
+		if err != nil {
 			return err
 		}
 
-		return edds.WriteWithOptions(img, path, &edds.WriteOptions{
-			Format:     cfg.Format,
-			MaxMipMaps: cfg.Mipmaps,
-			Compress:   true,
-			EncodeOptions: &bcn.EncodeOptions{
-				QualityLevel: cfg.Quality,
-				Workers:      0,
-			},
-		})
+		return edds.WriteFile(path, img, wopts)
 
 	default:
 		return fmt.Errorf("unsupported output format: %q", ext)
 	}
 }
+
+// eddsWriteOptions validates opts and returns the edds options of both DDS
+// and EDDS output, so both containers store the same mip chain and quality.
+func eddsWriteOptions(opts *EncodeSettings) (*edds.WriteOptions, error) {
+	cfg := effectiveEncodeSettings(opts)
+	if cfg.Mipmaps < 0 {
+		return nil, fmt.Errorf("mipmaps must be >= 0")
+	}
+	if cfg.MinMipSize < 0 {
+		return nil, fmt.Errorf("min mip size must be >= 0")
+	}
+	if err := ValidateQualityLevel(cfg.Quality); err != nil {
+		return nil, err
+	}
+
+	return &edds.WriteOptions{
+		Format:     cfg.Format,
+		MaxMips:    cfg.Mipmaps,
+		MinMipSize: cfg.MinMipSize,
+		MipCap:     cfg.MipCap,
+		Strategy:   cfg.Compression,
+		EncodeOptions: &bcn.EncodeOptions{
+			QualityLevel: cfg.Quality,
+			Workers:      0,
+		},
+	}, nil
+}
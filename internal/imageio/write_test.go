@@ -3,11 +3,13 @@ package imageio
 import (
 	"image"
 	"image/color"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/woozymasta/bcn"
-	"github.com/woozymasta/edds"
+
+	"github.com/woozymasta/imageset-packer/edds"
 )
 
 func TestWriteWithOptionsEDDSCompressed(t *testing.T) {
@@ -35,12 +37,82 @@ func TestWriteWithOptionsEDDSCompressed(t *testing.T) {
 		t.Fatalf("WriteWithOptions error: %v", err)
 	}
 
-	cfg, err := edds.ReadConfig(path)
+	cfg, err := edds.DecodeConfigFile(path)
 	if err != nil {
-		t.Fatalf("ReadConfig error: %v", err)
+		t.Fatalf("DecodeConfigFile error: %v", err)
 	}
 	if cfg.Width != 8 || cfg.Height != 8 {
-		t.Fatalf("ReadConfig size = %dx%d, want 8x8", cfg.Width, cfg.Height)
+		t.Fatalf("DecodeConfigFile size = %dx%d, want 8x8", cfg.Width, cfg.Height)
+	}
+}
+
+func TestBC5RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// A flat normal map: X and Y at 0.5, Z up.
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 128, G: 128, B: 255, A: 255})
+		}
+	}
+
+	for _, name := range []string{"normal.dds", "normal.edds"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := WriteWithOptions(path, img, &EncodeSettings{Format: mustParseFormat(t, "bc5")}); err != nil {
+			t.Fatalf("%s: WriteWithOptions: %v", name, err)
+		}
+
+		got, err := Read(path)
+		if err != nil {
+			t.Fatalf("%s: Read: %v", name, err)
+		}
+		c := color.NRGBAModel.Convert(got.At(3, 5)).(color.NRGBA)
+		if c.R < 126 || c.R > 130 || c.G < 126 || c.G > 130 {
+			t.Fatalf("%s: decoded %v, want red and green near 128", name, c)
+		}
+	}
+}
+
+func TestDDSMipChainMatchesEDDS(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	tests := []struct {
+		opts EncodeSettings
+		want int
+	}{
+		{opts: EncodeSettings{}, want: 7},
+		{opts: EncodeSettings{Mipmaps: 3}, want: 3},
+		{opts: EncodeSettings{MinMipSize: 4}, want: 4},
+		{opts: EncodeSettings{MipCap: 2}, want: 2},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		ddsPath, eddsPath := filepath.Join(dir, "a.dds"), filepath.Join(dir, "a.edds")
+		for _, path := range []string{ddsPath, eddsPath} {
+			if err := WriteWithOptions(path, img, &tt.opts); err != nil {
+				t.Fatalf("%+v: WriteWithOptions(%s): %v", tt.opts, path, err)
+			}
+		}
+
+		f, err := os.Open(ddsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hdr, err := bcn.ReadDDSHeader(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatalf("%+v: ReadDDSHeader: %v", tt.opts, err)
+		}
+		atlas, err := edds.ReadFile(eddsPath)
+		if err != nil {
+			t.Fatalf("%+v: edds.ReadFile: %v", tt.opts, err)
+		}
+		if int(hdr.MipMapCount) != tt.want || atlas.MipCount() != tt.want {
+			t.Fatalf("%+v: DDS has %d mips, EDDS %d, want %d", tt.opts, hdr.MipMapCount, atlas.MipCount(), tt.want)
+		}
 	}
 }
 
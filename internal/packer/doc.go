@@ -0,0 +1,8 @@
+// Package packer mirrors the MaxRects planner of github.com/woozymasta/atlasforge
+// for layouts its public API cannot express yet, such as sprites frozen at
+// their previous coordinates. Without those features Plan returns the same
+// layout as atlasforge.Plan; render the result with atlasforge.Render.
+//
+// maxrects.go, plan.go and util.go are forked from atlasforge v0.1.0 and
+// keep its license header; port upstream planner fixes from that version.
+package packer
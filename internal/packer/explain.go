@@ -0,0 +1,99 @@
+package packer
+
+import (
+	"fmt"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+// Candidate is one atlas size considered by the size search.
+type Candidate struct {
+	// Reason tells why the size was rejected; empty for the chosen size.
+	Reason string
+	// Score is the area weighted by the aspect penalty; lower is better.
+	// It is 0 for sizes rejected before scoring.
+	Score  float64
+	Width  int
+	Height int
+	Chosen bool
+}
+
+// Explanation describes the atlas size search of Plan.
+type Explanation struct {
+	// Candidates lists power-of-two sizes from MinSize to MaxSize in
+	// search order.
+	Candidates []Candidate
+	// Area is the padded area of all items.
+	Area int64
+	// LargestWidth and LargestHeight are the largest padded item sides.
+	LargestWidth  int
+	LargestHeight int
+	// Width and Height are the chosen size, 0 if no size up to MaxSize fits.
+	Width  int
+	Height int
+}
+
+// Explain runs the size search of Plan and reports every candidate size
+// with the reason it was rejected or chosen.
+func Explain(items []atlasforge.Item, opts Options) (*Explanation, error) {
+	work, err := prepareItems(items, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := newSearchSpace(work, opts)
+	heuristic := normalizeHeuristic(opts.Heuristic)
+	chosenW, chosenH := findOptimalSize(work, opts)
+	ex := &Explanation{Area: sp.area, LargestWidth: sp.largestW, LargestHeight: sp.largestH}
+	if chosenW <= opts.MaxSize && chosenH <= opts.MaxSize && canFit(work, chosenW, chosenH, opts, heuristic) {
+		ex.Width, ex.Height = chosenW, chosenH
+	}
+	chosenScore := candidateScore(chosenW, chosenH, opts.AspectPenalty)
+
+	explain := func(w, h int) Candidate {
+		c := Candidate{Width: w, Height: h}
+		switch {
+		case w < sp.start || h < sp.start:
+			c.Reason = fmt.Sprintf("below %dx%d, the start size for the largest item %dx%d", sp.start, sp.start, sp.largestW, sp.largestH)
+			return c
+		case w < sp.boundW || h < sp.boundH:
+			c.Reason = fmt.Sprintf("smaller than %dx%d held by frozen items or the minimum size", sp.boundW, sp.boundH)
+			return c
+		case opts.ForceSquare && w != h:
+			c.Reason = "not square (force square)"
+			return c
+		case !opts.ForceSquare && absPowerDiff(w, h) > 1:
+			c.Reason = "aspect ratio over 2:1"
+			return c
+		case sp.area > int64(w)*int64(h):
+			c.Reason = fmt.Sprintf("item area %d px exceeds %d px", sp.area, int64(w)*int64(h))
+			return c
+		}
+
+		c.Score = candidateScore(w, h, opts.AspectPenalty)
+		switch {
+		case w == ex.Width && h == ex.Height:
+			c.Chosen = true
+		case !canFit(work, w, h, opts, heuristic):
+			c.Reason = "items do not fit"
+		case heuristic == atlasforge.HeuristicFirstFit:
+			c.Reason = "fits, but first fit took an earlier size"
+		default:
+			c.Reason = fmt.Sprintf("score %.0f not better than %.0f of %dx%d", c.Score, chosenScore, chosenW, chosenH)
+		}
+
+		return c
+	}
+
+	for outer := opts.MinSize; outer <= opts.MaxSize; outer *= 2 {
+		for inner := opts.MinSize; inner <= opts.MaxSize; inner *= 2 {
+			w, h := inner, outer
+			if opts.PreferHeight {
+				w, h = outer, inner
+			}
+			ex.Candidates = append(ex.Candidates, explain(w, h))
+		}
+	}
+
+	return ex, nil
+}
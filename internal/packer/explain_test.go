@@ -0,0 +1,60 @@
+package packer
+
+import (
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+func TestExplainMatchesPlan(t *testing.T) {
+	t.Parallel()
+
+	for _, forceSquare := range []bool{false, true} {
+		opts := atlasforge.DefaultOptions()
+		opts.MinSize = 64
+		opts.MaxSize = 1024
+		opts.ForceSquare = forceSquare
+		items := testItems(40, 3)
+
+		layout, err := Plan(items, Options{Options: opts})
+		if err != nil {
+			t.Fatalf("Plan: %v", err)
+		}
+		ex, err := Explain(items, Options{Options: opts})
+		if err != nil {
+			t.Fatalf("Explain: %v", err)
+		}
+		if ex.Width != layout.Width || ex.Height != layout.Height {
+			t.Fatalf("explained %dx%d, planned %dx%d", ex.Width, ex.Height, layout.Width, layout.Height)
+		}
+		if len(ex.Candidates) != 25 {
+			t.Fatalf("got %d candidates, want 25", len(ex.Candidates))
+		}
+
+		chosen := 0
+		for _, c := range ex.Candidates {
+			if c.Chosen {
+				chosen++
+				if c.Width != layout.Width || c.Height != layout.Height {
+					t.Fatalf("chosen candidate %dx%d", c.Width, c.Height)
+				}
+			} else if c.Reason == "" {
+				t.Fatalf("candidate %dx%d rejected without reason", c.Width, c.Height)
+			}
+		}
+		if chosen != 1 {
+			t.Fatalf("got %d chosen candidates", chosen)
+		}
+	}
+
+	opts := atlasforge.DefaultOptions()
+	opts.MinSize = 64
+	opts.MaxSize = 128
+	ex, err := Explain(testItems(40, 3), Options{Options: opts})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if ex.Width != 0 || ex.Height != 0 {
+		t.Fatalf("explained %dx%d for items over MaxSize", ex.Width, ex.Height)
+	}
+}
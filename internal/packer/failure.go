@@ -0,0 +1,79 @@
+package packer
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+// maxSuggestSize bounds the search for a size that would have fit, the
+// largest texture side EDDS can hold.
+const maxSuggestSize = 16384
+
+// PlacementError reports an item that did not fit into the chosen atlas
+// size, with the bin state at that point. It wraps
+// atlasforge.ErrPlacementFailed.
+type PlacementError struct {
+	// ID is the item that did not fit.
+	ID string
+	// Placed holds items placed before the failure, frozen ones included.
+	Placed []atlasforge.Placement
+	// Free holds the free rectangles left in the bin, padding included.
+	Free []image.Rectangle
+	// ItemWidth and ItemHeight are the padded size of the failed item.
+	ItemWidth  int
+	ItemHeight int
+	// Width and Height are the atlas size placement was tried at.
+	Width  int
+	Height int
+	// NextWidth and NextHeight are the smallest size above MaxSize that
+	// fits every item, or 0 if none up to 16384 does.
+	NextWidth  int
+	NextHeight int
+}
+
+// Error implements error.
+func (e *PlacementError) Error() string {
+	return fmt.Sprintf("%v: item %q cannot fit into %dx%d", atlasforge.ErrPlacementFailed, e.ID, e.Width, e.Height)
+}
+
+// Unwrap returns atlasforge.ErrPlacementFailed.
+func (e *PlacementError) Unwrap() error {
+	return atlasforge.ErrPlacementFailed
+}
+
+// newPlacementError captures the bin state after item failed to fit.
+func newPlacementError(item atlasforge.Item, bin *maxRects, placed []atlasforge.Placement, width, height int, items []atlasforge.Item, opts Options) *PlacementError {
+	pad := opts.item(item.ID).Padding
+	e := &PlacementError{
+		ID:         item.ID,
+		Placed:     placed,
+		ItemWidth:  item.Width + 2*pad,
+		ItemHeight: item.Height + 2*pad,
+		Width:      width,
+		Height:     height,
+	}
+	for _, r := range bin.free {
+		e.Free = append(e.Free, image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H))
+	}
+	e.NextWidth, e.NextHeight = suggestSize(items, opts)
+
+	return e
+}
+
+// suggestSize returns the size the search picks with MaxSize lifted to
+// maxSuggestSize, or 0, 0 if nothing fits there either.
+func suggestSize(items []atlasforge.Item, opts Options) (int, int) {
+	if opts.MaxSize >= maxSuggestSize {
+		return 0, 0
+	}
+
+	opts.MaxSize = maxSuggestSize
+	w, h := findOptimalSize(items, opts)
+	if w > opts.MaxSize || h > opts.MaxSize || !canFit(items, w, h, opts, normalizeHeuristic(opts.Heuristic)) {
+		return 0, 0
+	}
+
+	return w, h
+}
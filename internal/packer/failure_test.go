@@ -0,0 +1,41 @@
+package packer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+func TestPlanPlacementError(t *testing.T) {
+	t.Parallel()
+
+	opts := atlasforge.DefaultOptions()
+	opts.MinSize = 64
+	opts.MaxSize = 1024
+	items := []atlasforge.Item{
+		{ID: "a", Width: 513, Height: 513},
+		{ID: "b", Width: 600, Height: 500},
+		{ID: "c", Width: 600, Height: 500},
+	}
+
+	_, err := Plan(items, Options{Options: opts})
+	if !errors.Is(err, atlasforge.ErrPlacementFailed) {
+		t.Fatalf("got %v, want ErrPlacementFailed", err)
+	}
+	var pe *PlacementError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %T, want *PlacementError", err)
+	}
+	if pe.ID != "a" || len(pe.Placed) != 2 || len(pe.Free) == 0 {
+		t.Fatalf("unexpected state: %+v", pe)
+	}
+	if pe.NextWidth != 2048 || pe.NextHeight != 1024 {
+		t.Fatalf("next size %dx%d, want 2048x1024", pe.NextWidth, pe.NextHeight)
+	}
+
+	opts.MaxSize = pe.NextWidth
+	if _, err := Plan(items, Options{Options: opts}); err != nil {
+		t.Fatalf("Plan at suggested size: %v", err)
+	}
+}
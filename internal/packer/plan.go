@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2026 WoozyMasta
+// Source: github.com/woozymasta/atlasforge v0.1.0
+
+package packer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+// Options extends atlasforge options with planner features atlasforge
+// does not expose.
+type Options struct {
+	// Frozen placements keep their position and size; the planner packs
+	// items around them. IDs must differ from item IDs.
+	Frozen []atlasforge.Placement
+
+	atlasforge.Options
+
+	// Items overrides padding and rotation of single items, frozen ones
+	// included, by ID; other items use Padding and AllowRotate.
+	Items map[string]ItemOptions
+
+	// MinWidth and MinHeight keep the atlas at least this large, e.g. at
+	// the previous size so UVs of frozen items stay valid.
+	MinWidth  int
+	MinHeight int
+}
+
+// ItemOptions are per-item planner options.
+type ItemOptions struct {
+	// Padding reserves empty pixels around the item.
+	Padding int
+	// AllowRotate lets the item be placed rotated by 90 degrees.
+	AllowRotate bool
+}
+
+// item returns the effective options of the item with id.
+func (o *Options) item(id string) ItemOptions {
+	if itemOpts, ok := o.Items[id]; ok {
+		return itemOpts
+	}
+
+	return ItemOptions{Padding: o.Padding, AllowRotate: o.AllowRotate}
+}
+
+// Plan computes atlas placements like atlasforge.Plan. Frozen placements
+// are reserved first, with padding, and returned unchanged.
+func Plan(items []atlasforge.Item, opts Options) (*atlasforge.Layout, error) {
+	work, err := prepareItems(items, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := findOptimalSize(work, opts)
+	if width > opts.MaxSize || height > opts.MaxSize {
+		return nil, fmt.Errorf(
+			"%w: required atlas size %dx%d exceeds MaxSize=%d",
+			atlasforge.ErrInvalidOptions,
+			width,
+			height,
+			opts.MaxSize,
+		)
+	}
+
+	bin := newBin(width, height, opts)
+	placements := make([]atlasforge.Placement, 0, len(work)+len(opts.Frozen))
+	placements = append(placements, opts.Frozen...)
+	heuristic := normalizeHeuristic(opts.Heuristic)
+
+	for _, item := range work {
+		itemOpts := opts.item(item.ID)
+		rect, ok := bin.Insert(item.Width+2*itemOpts.Padding, item.Height+2*itemOpts.Padding, heuristic, itemOpts.AllowRotate)
+		if !ok {
+			return nil, newPlacementError(item, bin, placements, width, height, work, opts)
+		}
+
+		placements = append(placements, atlasforge.Placement{
+			ID:      item.ID,
+			X:       rect.X + itemOpts.Padding,
+			Y:       rect.Y + itemOpts.Padding,
+			Width:   item.Width,
+			Height:  item.Height,
+			Rotated: rect.Rotated,
+		})
+	}
+
+	return &atlasforge.Layout{
+		Width:      width,
+		Height:     height,
+		Placements: placements,
+	}, nil
+}
+
+// prepareItems validates options and items and returns a sorted copy of
+// the items.
+func prepareItems(items []atlasforge.Item, opts Options) ([]atlasforge.Item, error) {
+	if opts.MinSize <= 0 || opts.MaxSize <= 0 || opts.MinSize > opts.MaxSize || opts.Padding < 0 {
+		return nil, fmt.Errorf(
+			"%w: MinSize=%d MaxSize=%d Padding=%d",
+			atlasforge.ErrInvalidOptions,
+			opts.MinSize,
+			opts.MaxSize,
+			opts.Padding,
+		)
+	}
+	for id, itemOpts := range opts.Items {
+		if itemOpts.Padding < 0 {
+			return nil, fmt.Errorf("%w: item %q has Padding=%d", atlasforge.ErrInvalidOptions, id, itemOpts.Padding)
+		}
+	}
+	if err := validateItems(items, opts.Frozen); err != nil {
+		return nil, err
+	}
+
+	work := make([]atlasforge.Item, len(items))
+	copy(work, items)
+	sortItemsForPacking(work, &opts)
+
+	return work, nil
+}
+
+// validateItems validates item IDs and sizes and checks that frozen
+// placements neither share IDs nor overlap.
+func validateItems(items []atlasforge.Item, frozen []atlasforge.Placement) error {
+	seen := make(map[string]struct{}, len(items)+len(frozen))
+	check := func(id string, w, h int) error {
+		if id == "" {
+			return fmt.Errorf("%w: empty item ID", atlasforge.ErrInvalidItem)
+		}
+		if w <= 0 || h <= 0 {
+			return fmt.Errorf("%w: item %q has invalid size %dx%d", atlasforge.ErrInvalidItem, id, w, h)
+		}
+		if _, exists := seen[id]; exists {
+			return fmt.Errorf("%w: duplicate item ID %q", atlasforge.ErrInvalidItem, id)
+		}
+		seen[id] = struct{}{}
+		return nil
+	}
+
+	for _, item := range items {
+		if err := check(item.ID, item.Width, item.Height); err != nil {
+			return err
+		}
+	}
+	for i, a := range frozen {
+		if err := check(a.ID, a.Width, a.Height); err != nil {
+			return err
+		}
+		if a.X < 0 || a.Y < 0 {
+			return fmt.Errorf("%w: frozen item %q at negative position %d,%d", atlasforge.ErrInvalidItem, a.ID, a.X, a.Y)
+		}
+		for _, b := range frozen[:i] {
+			aw, ah := placedSize(a)
+			bw, bh := placedSize(b)
+			if a.X < b.X+bw && b.X < a.X+aw && a.Y < b.Y+bh && b.Y < a.Y+ah {
+				return fmt.Errorf("%w: frozen items %q and %q overlap", atlasforge.ErrInvalidItem, b.ID, a.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// placedSize returns the atlas footprint of a placement.
+func placedSize(p atlasforge.Placement) (int, int) {
+	if p.Rotated {
+		return p.Height, p.Width
+	}
+
+	return p.Width, p.Height
+}
+
+// frozenExtent returns the smallest atlas size holding all frozen placements.
+func frozenExtent(frozen []atlasforge.Placement) (int, int) {
+	w, h := 0, 0
+	for _, p := range frozen {
+		pw, ph := placedSize(p)
+		w = max(w, p.X+pw)
+		h = max(h, p.Y+ph)
+	}
+
+	return w, h
+}
+
+// newBin returns a MaxRects bin with frozen placements and their padding
+// already occupied.
+func newBin(width, height int, opts Options) *maxRects {
+	bin := newMaxRects(width, height)
+	for _, p := range opts.Frozen {
+		pw, ph := placedSize(p)
+		pad := opts.item(p.ID).Padding
+		bin.Occupy(mrRect{
+			X: p.X - pad,
+			Y: p.Y - pad,
+			W: pw + 2*pad,
+			H: ph + 2*pad,
+		})
+	}
+
+	return bin
+}
+
+// sortItemsForPacking sorts bigger items first for better packing density.
+func sortItemsForPacking(items []atlasforge.Item, opts *Options) {
+	sort.Slice(items, func(i, j int) bool {
+		pi := opts.item(items[i].ID).Padding
+		pj := opts.item(items[j].ID).Padding
+		wi := items[i].Width + 2*pi
+		hi := items[i].Height + 2*pi
+		wj := items[j].Width + 2*pj
+		hj := items[j].Height + 2*pj
+
+		mi := max(wi, hi)
+		mj := max(wj, hj)
+		if mi != mj {
+			return mi > mj
+		}
+
+		ai := wi * hi
+		aj := wj * hj
+		if ai != aj {
+			return ai > aj
+		}
+
+		if hi != hj {
+			return hi > hj
+		}
+
+		return wi > wj
+	})
+}
+
+// searchSpace holds the inputs of the atlas size search.
+type searchSpace struct {
+	// area is the padded area of all items, frozen ones included.
+	area int64
+	// largestW and largestH are the largest padded item sides.
+	largestW int
+	largestH int
+	// start is the smallest square candidate holding the largest item.
+	start int
+	// boundW and boundH are the frozen extent raised to the minimum size.
+	// They bound each side separately and do not raise the square start.
+	boundW int
+	boundH int
+}
+
+// newSearchSpace computes the size search inputs for sorted items.
+func newSearchSpace(items []atlasforge.Item, opts Options) searchSpace {
+	var sp searchSpace
+	for _, p := range opts.Frozen {
+		pad := opts.item(p.ID).Padding
+		sp.area += int64(p.Width+2*pad) * int64(p.Height+2*pad)
+	}
+	for _, item := range items {
+		pad := opts.item(item.ID).Padding
+		w := item.Width + 2*pad
+		h := item.Height + 2*pad
+		sp.largestW = max(sp.largestW, w)
+		sp.largestH = max(sp.largestH, h)
+		sp.area += int64(w) * int64(h)
+	}
+
+	sp.start = opts.MinSize
+	if sp.largestW > sp.start {
+		sp.start = nextPowerOfTwo(sp.largestW)
+	}
+	if sp.largestH > sp.start {
+		sp.start = nextPowerOfTwo(sp.largestH)
+	}
+	sp.boundW, sp.boundH = frozenExtent(opts.Frozen)
+	sp.boundW, sp.boundH = max(sp.boundW, opts.MinWidth), max(sp.boundH, opts.MinHeight)
+
+	return sp
+}
+
+// findOptimalSize searches candidate atlas sizes and returns the best fit.
+// Candidates smaller than the frozen extent or the minimum size are skipped.
+func findOptimalSize(items []atlasforge.Item, opts Options) (width, height int) {
+	sp := newSearchSpace(items, opts)
+	size := sp.start
+	heuristic := normalizeHeuristic(opts.Heuristic)
+
+	fits := func(w, h int) bool {
+		if w > opts.MaxSize || h > opts.MaxSize || w < sp.boundW || h < sp.boundH {
+			return false
+		}
+		if opts.ForceSquare {
+			if w != h {
+				return false
+			}
+		} else if absPowerDiff(w, h) > 1 {
+			return false
+		}
+
+		return sp.area <= int64(w)*int64(h)
+	}
+
+	bestW, bestH := size, size
+	bestScore := math.MaxFloat64
+	try := func(w, h int) bool {
+		if !fits(w, h) {
+			return false
+		}
+
+		score := candidateScore(w, h, opts.AspectPenalty)
+		if heuristic != atlasforge.HeuristicFirstFit && !isBetterCandidate(w, h, score, bestW, bestH, bestScore, opts.PreferHeight) {
+			return false
+		}
+		if !canFit(items, w, h, opts, heuristic) {
+			return false
+		}
+
+		bestScore = score
+		bestW, bestH = w, h
+		return heuristic == atlasforge.HeuristicFirstFit
+	}
+
+	// FirstFit takes the first candidate that fits, in iteration order.
+	if opts.ForceSquare {
+		for s := size; s <= opts.MaxSize; s *= 2 {
+			if try(s, s) {
+				return bestW, bestH
+			}
+		}
+		return bestW, bestH
+	}
+
+	for outer := size; outer <= opts.MaxSize; outer *= 2 {
+		for inner := size; inner <= opts.MaxSize; inner *= 2 {
+			w, h := inner, outer
+			if opts.PreferHeight {
+				w, h = outer, inner
+			}
+			if try(w, h) {
+				return bestW, bestH
+			}
+		}
+	}
+
+	return bestW, bestH
+}
+
+// candidateScore returns weighted area score used by optimal size search.
+func candidateScore(w, h int, aspectPenalty float64) float64 {
+	aspect := float64(max(w, h)) / float64(min(w, h))
+
+	return float64(w*h) * (1.0 + aspectPenalty*(aspect-1.0))
+}
+
+// isBetterCandidate checks if candidate could replace current best by score/tie policy.
+func isBetterCandidate(w, h int, score float64, bestW, bestH int, bestScore float64, preferHeight bool) bool {
+	if score != bestScore {
+		return score < bestScore
+	}
+
+	if preferHeight {
+		if h != bestH {
+			return h > bestH
+		}
+		return w > bestW
+	}
+
+	if w != bestW {
+		return w > bestW
+	}
+	return h > bestH
+}
+
+// canFit dry-runs placement for all items around frozen placements.
+func canFit(items []atlasforge.Item, w, h int, opts Options, heuristic atlasforge.Heuristic) bool {
+	bin := newBin(w, h, opts)
+	for _, item := range items {
+		itemOpts := opts.item(item.ID)
+		if _, ok := bin.Insert(item.Width+2*itemOpts.Padding, item.Height+2*itemOpts.Padding, heuristic, itemOpts.AllowRotate); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeHeuristic normalizes out-of-range heuristic values.
+func normalizeHeuristic(heuristic atlasforge.Heuristic) atlasforge.Heuristic {
+	if heuristic < atlasforge.HeuristicBestShortSideFit || heuristic > atlasforge.HeuristicFirstFit {
+		return atlasforge.HeuristicBestShortSideFit
+	}
+
+	return heuristic
+}
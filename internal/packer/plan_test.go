@@ -0,0 +1,176 @@
+package packer
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+)
+
+// testItems returns n items of pseudo-random size.
+func testItems(n int, seed int64) []atlasforge.Item {
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic test data
+	items := make([]atlasforge.Item, n)
+	for i := range items {
+		items[i] = atlasforge.Item{
+			ID:     fmt.Sprintf("item%02d", i),
+			Width:  8 + rng.Intn(120),
+			Height: 8 + rng.Intn(120),
+		}
+	}
+
+	return items
+}
+
+func TestPlanMatchesAtlasforge(t *testing.T) {
+	t.Parallel()
+
+	heuristics := []atlasforge.Heuristic{
+		atlasforge.HeuristicBestShortSideFit,
+		atlasforge.HeuristicBestLongSideFit,
+		atlasforge.HeuristicBestAreaFit,
+		atlasforge.HeuristicBottomLeft,
+		atlasforge.HeuristicContactPoint,
+		atlasforge.HeuristicFirstFit,
+	}
+
+	for _, heuristic := range heuristics {
+		for _, variant := range []func(*atlasforge.Options){
+			func(*atlasforge.Options) {},
+			func(o *atlasforge.Options) { o.Padding = 2; o.AllowRotate = true },
+			func(o *atlasforge.Options) { o.ForceSquare = true },
+			func(o *atlasforge.Options) { o.PreferHeight = true; o.AspectPenalty = 0 },
+		} {
+			opts := atlasforge.DefaultOptions()
+			opts.MinSize = 64
+			opts.Heuristic = heuristic
+			variant(&opts)
+			items := testItems(40, int64(heuristic))
+
+			want, err := atlasforge.Plan(items, opts)
+			if err != nil {
+				t.Fatalf("atlasforge.Plan: %v", err)
+			}
+			got, err := Plan(items, Options{Options: opts})
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("heuristic %d %+v: layout differs from atlasforge", heuristic, opts)
+			}
+		}
+	}
+}
+
+func TestPlanFrozen(t *testing.T) {
+	t.Parallel()
+
+	opts := atlasforge.DefaultOptions()
+	opts.MinSize = 64
+	opts.Padding = 1
+	frozen := []atlasforge.Placement{
+		{ID: "frozen0", X: 200, Y: 300, Width: 40, Height: 20},
+		{ID: "frozen1", X: 1, Y: 1, Width: 64, Height: 64},
+	}
+
+	layout, err := Plan(testItems(30, 7), Options{Options: opts, Frozen: frozen})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if layout.Width < 240 || layout.Height < 320 {
+		t.Fatalf("atlas %dx%d does not hold frozen items", layout.Width, layout.Height)
+	}
+
+	placed := make(map[string]atlasforge.Placement, len(layout.Placements))
+	for _, p := range layout.Placements {
+		placed[p.ID] = p
+	}
+	for _, f := range frozen {
+		if placed[f.ID] != f {
+			t.Fatalf("frozen %s moved to %+v", f.ID, placed[f.ID])
+		}
+	}
+
+	for i, a := range layout.Placements {
+		aw, ah := placedSize(a)
+		for _, b := range layout.Placements[:i] {
+			bw, bh := placedSize(b)
+			if a.X < b.X+bw+opts.Padding && b.X < a.X+aw+opts.Padding && a.Y < b.Y+bh+opts.Padding && b.Y < a.Y+ah+opts.Padding {
+				t.Fatalf("%s and %s overlap or break padding", a.ID, b.ID)
+			}
+		}
+	}
+
+	overlapping := []atlasforge.Placement{frozen[1], {ID: "x", X: 10, Y: 10, Width: 4, Height: 4}}
+	if _, err := Plan(nil, Options{Options: opts, Frozen: overlapping}); err == nil {
+		t.Fatal("Plan accepted overlapping frozen items")
+	}
+}
+
+func TestPlanMinSizeKeepsShape(t *testing.T) {
+	t.Parallel()
+
+	opts := atlasforge.DefaultOptions()
+	opts.MinSize = 64
+	opts.AspectPenalty = 0
+
+	layout, err := Plan(testItems(3, 1), Options{Options: opts, MinWidth: 512, MinHeight: 256})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if layout.Width != 512 || layout.Height != 256 {
+		t.Fatalf("atlas %dx%d, want 512x256", layout.Width, layout.Height)
+	}
+}
+
+func TestPlanItemOptions(t *testing.T) {
+	t.Parallel()
+
+	opts := atlasforge.DefaultOptions()
+	opts.MinSize = 64
+	opts.Padding = 1
+	opts.AllowRotate = true
+
+	items := testItems(40, 3)
+	overrides := make(map[string]ItemOptions)
+	for i, item := range items {
+		if i%2 == 0 {
+			overrides[item.ID] = ItemOptions{Padding: 4}
+		}
+	}
+
+	layout, err := Plan(items, Options{Options: opts, Items: overrides})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	pad := func(id string) int {
+		if o, ok := overrides[id]; ok {
+			return o.Padding
+		}
+		return opts.Padding
+	}
+	for i, a := range layout.Placements {
+		if _, ok := overrides[a.ID]; ok && a.Rotated {
+			t.Fatalf("%s rotated although rotation is disabled for it", a.ID)
+		}
+		aw, ah := placedSize(a)
+		pa := pad(a.ID)
+		if a.X < pa || a.Y < pa || a.X+aw+pa > layout.Width || a.Y+ah+pa > layout.Height {
+			t.Fatalf("%s at %d,%d breaks its padding %d to the atlas edge", a.ID, a.X, a.Y, pa)
+		}
+		for _, b := range layout.Placements[:i] {
+			bw, bh := placedSize(b)
+			gap := max(pa, pad(b.ID))
+			if a.X < b.X+bw+gap && b.X < a.X+aw+gap && a.Y < b.Y+bh+gap && b.Y < a.Y+ah+gap {
+				t.Fatalf("%s and %s overlap or break padding %d", a.ID, b.ID, gap)
+			}
+		}
+	}
+
+	if _, err := Plan(items, Options{Options: opts, Items: map[string]ItemOptions{"item00": {Padding: -1}}}); err == nil {
+		t.Fatal("Plan accepted negative item padding")
+	}
+}
@@ -0,0 +1,30 @@
+/*
+Package pack packs sprites into an atlas texture and describes the result as
+an imageset, for integrations that build atlases without the pack command.
+
+Pack places sprites with the MaxRects rules of the pack command (--rule),
+but applies only the options it is given: there is no DXT format profile
+(quality 8 and 4x4 block aligned slots), no gap check and no group
+overrides. Set WithQuality and a gap that suits the format explicitly.
+
+Options are set with Option functions, so new packing features add new With
+functions instead of changing the signature of Pack:
+
+	res, err := pack.Pack(sprites,
+		pack.WithGap(2),
+		pack.WithFormat(bcn.FormatDXT5),
+		pack.WithQuality(8),
+		pack.WithRule(pack.RuleBottomLeft),
+		pack.WithWorkers(4),
+	)
+
+A Result carries a Version. Fields are only added to a Result within a
+version; ResultVersion changes when the meaning of an existing field does,
+so integrations that store results (e.g. as JSON) can detect old ones.
+
+Result.WriteEDDS encodes the atlas with the format, quality, mip and
+compression options the result was packed with, and Result.Imageset returns
+the imageset document that references it. Result.Options is stored with
+the result, so a stored result can be encoded again the same way.
+*/
+package pack
@@ -0,0 +1,222 @@
+package pack
+
+import (
+	"fmt"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/bcn"
+
+	"github.com/woozymasta/imageset-packer/edds"
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// Rule is a MaxRects placement rule, named as the --rule values of the pack
+// command.
+type Rule string
+
+const (
+	// RuleBestShortSideFit minimizes the smaller leftover edge first.
+	RuleBestShortSideFit Rule = "bssf"
+	// RuleBestLongSideFit minimizes the larger leftover edge first.
+	RuleBestLongSideFit Rule = "blsf"
+	// RuleBestAreaFit minimizes wasted free area first.
+	RuleBestAreaFit Rule = "baf"
+	// RuleBottomLeft prefers lower, then further left placements.
+	RuleBottomLeft Rule = "bl"
+	// RuleContactPoint maximizes contact with borders and placed sprites.
+	RuleContactPoint Rule = "cp"
+	// RuleFirstFit places into the first free area that fits; fastest.
+	RuleFirstFit Rule = "ff"
+)
+
+// Compression is an EDDS block compression, named as the --compression
+// values of the pack command.
+type Compression string
+
+const (
+	// CompressionHC compresses every mip with LZ4 HC; smallest files.
+	CompressionHC Compression = "hc"
+	// CompressionBalanced uses LZ4 HC for the base mip and fast LZ4 for
+	// the rest.
+	CompressionBalanced Compression = "balanced"
+	// CompressionFast compresses every mip with fast LZ4.
+	CompressionFast Compression = "fast"
+	// CompressionNone stores raw blocks.
+	CompressionNone Compression = "none"
+)
+
+// heuristic returns the atlasforge heuristic of r.
+func (r Rule) heuristic() (atlasforge.Heuristic, error) {
+	switch r {
+	case RuleBestShortSideFit:
+		return atlasforge.HeuristicBestShortSideFit, nil
+	case RuleBestLongSideFit:
+		return atlasforge.HeuristicBestLongSideFit, nil
+	case RuleBestAreaFit:
+		return atlasforge.HeuristicBestAreaFit, nil
+	case RuleBottomLeft:
+		return atlasforge.HeuristicBottomLeft, nil
+	case RuleContactPoint:
+		return atlasforge.HeuristicContactPoint, nil
+	case RuleFirstFit:
+		return atlasforge.HeuristicFirstFit, nil
+	default:
+		return 0, fmt.Errorf("unknown rule %q", r)
+	}
+}
+
+// Options configures Pack. Use DefaultOptions and Option functions rather
+// than a struct literal: fields added later get their defaults only there.
+type Options struct {
+	// Rule is the placement rule.
+	Rule Rule `json:"rule"`
+	// Compression is the EDDS block compression of Result.WriteEDDS.
+	Compression Compression `json:"compression"`
+	// Format is the pixel format written by Result.WriteEDDS.
+	Format bcn.Format `json:"format"`
+	// MinSize and MaxSize bound the atlas sides, both powers of two.
+	MinSize int `json:"min_size"`
+	MaxSize int `json:"max_size"`
+	// Gap is the empty margin kept around every sprite.
+	Gap int `json:"gap"`
+	// Quality is the DXT quality level 1..10, 0 = encoder default.
+	Quality int `json:"quality"`
+	// Mipmaps limits the mip levels written by Result.WriteEDDS,
+	// 0 = full chain.
+	Mipmaps int `json:"mipmaps"`
+	// MipCap caps the full chain written for Mipmaps 0, as
+	// edds.WriteOptions.MipCap: 0 = edds.DefaultMipCap, edds.NoMipCap =
+	// down to 1x1.
+	MipCap int `json:"mip_cap"`
+	// MinMipSize ends the chain before a level with a side below it,
+	// 0 = down to 1x1.
+	MinMipSize int `json:"min_mip_size"`
+	// Workers limits parallel block encoding, 0 = GOMAXPROCS.
+	Workers int `json:"workers"`
+	// AllowRotate lets sprites be placed rotated by 90 degrees clockwise.
+	AllowRotate bool `json:"allow_rotate"`
+}
+
+// Option changes Options.
+type Option func(*Options)
+
+// DefaultOptions returns BGRA8 with LZ4 HC compression, the bottom-left
+// rule, atlas sides from 256 to 4096 and no gap, as the pack command
+// flags default to.
+func DefaultOptions() Options {
+	return Options{
+		Rule:        RuleBottomLeft,
+		Compression: CompressionHC,
+		Format:      bcn.FormatBGRA8,
+		MinSize:     256,
+		MaxSize:     4096,
+	}
+}
+
+// NewOptions returns DefaultOptions changed by opts.
+func NewOptions(opts ...Option) Options {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithOptions replaces all options with o, e.g. a stored configuration.
+func WithOptions(o Options) Option {
+	return func(dst *Options) { *dst = o }
+}
+
+// WithGap sets the empty margin kept around every sprite.
+func WithGap(gap int) Option {
+	return func(o *Options) { o.Gap = gap }
+}
+
+// WithFormat sets the pixel format written by Result.WriteEDDS.
+func WithFormat(format bcn.Format) Option {
+	return func(o *Options) { o.Format = format }
+}
+
+// WithRule sets the placement rule.
+func WithRule(rule Rule) Option {
+	return func(o *Options) { o.Rule = rule }
+}
+
+// WithWorkers limits parallel block encoding; 0 uses GOMAXPROCS.
+func WithWorkers(workers int) Option {
+	return func(o *Options) { o.Workers = workers }
+}
+
+// WithSize bounds the atlas sides to powers of two from minSize to maxSize.
+func WithSize(minSize, maxSize int) Option {
+	return func(o *Options) { o.MinSize, o.MaxSize = minSize, maxSize }
+}
+
+// WithRotation lets sprites be placed rotated by 90 degrees clockwise.
+func WithRotation(allow bool) Option {
+	return func(o *Options) { o.AllowRotate = allow }
+}
+
+// WithQuality sets the DXT quality level 1..10; 0 is the encoder default.
+func WithQuality(quality int) Option {
+	return func(o *Options) { o.Quality = quality }
+}
+
+// WithMipmaps limits the mip levels written by Result.WriteEDDS; 0 writes
+// a full chain.
+func WithMipmaps(mipmaps int) Option {
+	return func(o *Options) { o.Mipmaps = mipmaps }
+}
+
+// WithMipCap caps the full chain written for Mipmaps 0; 0 keeps
+// edds.DefaultMipCap and edds.NoMipCap writes down to 1x1.
+func WithMipCap(mipCap int) Option {
+	return func(o *Options) { o.MipCap = mipCap }
+}
+
+// WithMinMipSize ends the chain written by Result.WriteEDDS before a level
+// with a side below size, e.g. 4 for block aligned DXT levels.
+func WithMinMipSize(size int) Option {
+	return func(o *Options) { o.MinMipSize = size }
+}
+
+// WithCompression sets the EDDS block compression of Result.WriteEDDS.
+func WithCompression(c Compression) Option {
+	return func(o *Options) { o.Compression = c }
+}
+
+// validate checks o and returns the atlasforge options of its packing part.
+func (o *Options) validate() (atlasforge.Options, error) {
+	heuristic, err := o.Rule.heuristic()
+	if err != nil {
+		return atlasforge.Options{}, err
+	}
+	switch {
+	case o.Gap < 0:
+		return atlasforge.Options{}, fmt.Errorf("gap must be >= 0")
+	case o.Workers < 0:
+		return atlasforge.Options{}, fmt.Errorf("workers must be >= 0")
+	case o.Mipmaps < 0:
+		return atlasforge.Options{}, fmt.Errorf("mipmaps must be >= 0")
+	case o.MinMipSize < 0:
+		return atlasforge.Options{}, fmt.Errorf("min mip size must be >= 0")
+	case o.MipCap < edds.NoMipCap:
+		return atlasforge.Options{}, fmt.Errorf("mip cap must be >= %d", edds.NoMipCap)
+	case o.Quality < 0 || o.Quality > 10:
+		return atlasforge.Options{}, fmt.Errorf("quality must be in 0..10")
+	}
+
+	if _, err := imageio.ParseCompression(string(o.Compression)); err != nil {
+		return atlasforge.Options{}, err
+	}
+
+	af := atlasforge.DefaultOptions()
+	af.MinSize = o.MinSize
+	af.MaxSize = o.MaxSize
+	af.Padding = o.Gap
+	af.AllowRotate = o.AllowRotate
+	af.Heuristic = heuristic
+
+	return af, nil
+}
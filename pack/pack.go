@@ -0,0 +1,177 @@
+package pack
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/bcn"
+	"github.com/woozymasta/imageset"
+
+	"github.com/woozymasta/imageset-packer/edds"
+	"github.com/woozymasta/imageset-packer/internal/imageio"
+)
+
+// ResultVersion is the Version of results returned by this package.
+const ResultVersion = 1
+
+// Sprite is one image to pack.
+type Sprite struct {
+	// Image holds the sprite pixels; its bounds give the sprite size.
+	Image image.Image
+	// Name is the imageset name of the sprite, unique within Group.
+	Name string
+	// Group is the imageset group of the sprite, empty for none.
+	Group string
+}
+
+// Placement is where a sprite was placed in the atlas.
+type Placement struct {
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	// Width and Height are the sprite size before rotation. A rotated
+	// sprite is turned 90 degrees clockwise and covers Height x Width
+	// atlas pixels.
+	Width   int  `json:"width"`
+	Height  int  `json:"height"`
+	Rotated bool `json:"rotated,omitempty"`
+}
+
+// Result is a packed atlas.
+type Result struct {
+	// Atlas is the rendered atlas.
+	Atlas image.Image `json:"-"`
+	// Sprites holds one placement per packed sprite, in input order.
+	Sprites []Placement `json:"sprites"`
+	// Options are the options the atlas was packed with.
+	Options Options `json:"options"`
+	// Version is the ResultVersion of the package that packed the atlas.
+	Version int `json:"version"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Gap     int `json:"gap"`
+}
+
+// Pack packs sprites into one atlas with DefaultOptions changed by opts.
+func Pack(sprites []Sprite, opts ...Option) (*Result, error) {
+	o := NewOptions(opts...)
+	cfg, err := o.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", atlasforge.ErrInvalidOptions, err)
+	}
+	if len(sprites) == 0 {
+		return nil, fmt.Errorf("no sprites to pack")
+	}
+
+	seen := make(map[[2]string]bool, len(sprites))
+	items := make([]atlasforge.Sprite, len(sprites))
+	for i, s := range sprites {
+		if s.Name == "" || s.Image == nil {
+			return nil, fmt.Errorf("sprite %d: name and image are required", i)
+		}
+		key := [2]string{s.Group, s.Name}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate sprite %q in group %q", s.Name, s.Group)
+		}
+		seen[key] = true
+
+		b := s.Image.Bounds()
+		items[i] = atlasforge.Sprite{ID: strconv.Itoa(i), Width: b.Dx(), Height: b.Dy(), Image: s.Image}
+	}
+
+	atlas, err := atlasforge.Pack(items, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Version: ResultVersion,
+		Atlas:   atlas.Image,
+		Options: o,
+		Width:   atlas.Layout.Width,
+		Height:  atlas.Layout.Height,
+		Gap:     o.Gap,
+		Sprites: make([]Placement, len(sprites)),
+	}
+	for _, p := range atlas.Layout.Placements {
+		i, err := strconv.Atoi(p.ID)
+		if err != nil || i < 0 || i >= len(sprites) {
+			return nil, fmt.Errorf("unexpected placement %q", p.ID)
+		}
+		res.Sprites[i] = Placement{
+			Name:    sprites[i].Name,
+			Group:   sprites[i].Group,
+			X:       p.X,
+			Y:       p.Y,
+			Width:   p.Width,
+			Height:  p.Height,
+			Rotated: p.Rotated,
+		}
+	}
+
+	return res, nil
+}
+
+// WriteEDDS encodes the atlas as EDDS with the format, quality, mip,
+// compression and worker options of the result.
+func (r *Result) WriteEDDS(w io.Writer) error {
+	if r.Atlas == nil {
+		return fmt.Errorf("result has no atlas image")
+	}
+	strategy, err := imageio.ParseCompression(string(r.Options.Compression))
+	if err != nil {
+		return err
+	}
+
+	return edds.Encode(w, r.Atlas, &edds.WriteOptions{
+		Format:     r.Options.Format,
+		Strategy:   strategy,
+		MaxMips:    r.Options.Mipmaps,
+		MipCap:     r.Options.MipCap,
+		MinMipSize: r.Options.MinMipSize,
+		EncodeOptions: &bcn.EncodeOptions{
+			QualityLevel: r.Options.Quality,
+			Workers:      r.Options.Workers,
+		},
+	})
+}
+
+// Imageset returns the imageset document named name that maps the sprites
+// onto the texture at texturePath. Groups are sorted by name.
+func (r *Result) Imageset(name, texturePath string) *imageset.Document {
+	doc := &imageset.Document{
+		Name:     name,
+		RefSize:  imageset.Size{Width: r.Width, Height: r.Height},
+		Textures: []imageset.Texture{{Mpix: 1, Path: texturePath}},
+	}
+
+	groups := make(map[string][]imageset.Image)
+	for _, p := range r.Sprites {
+		img := imageset.Image{
+			Name: p.Name,
+			Pos:  imageset.Point{X: p.X, Y: p.Y},
+			Size: imageset.Size{Width: p.Width, Height: p.Height},
+		}
+		if p.Group == "" {
+			doc.Images = append(doc.Images, img)
+		} else {
+			groups[p.Group] = append(groups[p.Group], img)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for g := range groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		doc.Groups = append(doc.Groups, imageset.Group{Name: g, Images: groups[g]})
+	}
+
+	return doc
+}
@@ -0,0 +1,153 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/woozymasta/atlasforge"
+	"github.com/woozymasta/bcn"
+
+	"github.com/woozymasta/imageset-packer/edds"
+)
+
+func testSprite(name, group string, w, h int, c color.NRGBA) Sprite {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	return Sprite{Name: name, Group: group, Image: img}
+}
+
+func TestNewOptions(t *testing.T) {
+	t.Parallel()
+
+	o := NewOptions(WithGap(2), WithFormat(bcn.FormatDXT5), WithRule(RuleFirstFit), WithWorkers(3))
+	want := DefaultOptions()
+	want.Gap, want.Format, want.Rule, want.Workers = 2, bcn.FormatDXT5, RuleFirstFit, 3
+	if o != want {
+		t.Fatalf("NewOptions = %+v, want %+v", o, want)
+	}
+
+	if got := NewOptions(WithGap(2), WithOptions(DefaultOptions())); got != DefaultOptions() {
+		t.Fatalf("WithOptions did not replace earlier options: %+v", got)
+	}
+}
+
+func TestPack(t *testing.T) {
+	t.Parallel()
+
+	red := color.NRGBA{R: 255, A: 255}
+	sprites := []Sprite{
+		testSprite("a", "", 40, 20, red),
+		testSprite("b", "icons", 16, 16, color.NRGBA{G: 255, A: 255}),
+		testSprite("c", "icons", 8, 30, color.NRGBA{B: 255, A: 255}),
+	}
+
+	res, err := Pack(sprites, WithGap(2), WithSize(64, 256))
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if res.Version != ResultVersion || res.Gap != 2 || res.Width < 64 || len(res.Sprites) != len(sprites) {
+		t.Fatalf("Pack = version %d gap %d %dx%d with %d sprites", res.Version, res.Gap, res.Width, res.Height, len(res.Sprites))
+	}
+	for i, p := range res.Sprites {
+		if p.Name != sprites[i].Name || p.Group != sprites[i].Group {
+			t.Fatalf("sprite %d = %s/%s, want input order", i, p.Group, p.Name)
+		}
+	}
+	a := res.Sprites[0]
+	if got := color.NRGBAModel.Convert(res.Atlas.At(a.X+1, a.Y+1)); got != red {
+		t.Fatalf("atlas pixel of a = %v, want %v", got, red)
+	}
+
+	doc := res.Imageset("set", "mod/data/set.edds")
+	if len(doc.Images) != 1 || len(doc.Groups) != 1 || len(doc.Groups[0].Images) != 2 {
+		t.Fatalf("Imageset = %d images, %d groups", len(doc.Images), len(doc.Groups))
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteEDDS(&buf); err != nil {
+		t.Fatalf("WriteEDDS: %v", err)
+	}
+	cfg, err := edds.DecodeConfig(&buf)
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if cfg.Width != res.Width || cfg.Height != res.Height {
+		t.Fatalf("EDDS size = %dx%d, want %dx%d", cfg.Width, cfg.Height, res.Width, res.Height)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	t.Parallel()
+
+	sprite := testSprite("a", "", 8, 8, color.NRGBA{A: 255})
+	res, err := Pack([]Sprite{sprite}, WithFormat(bcn.FormatDXT5), WithCompression(CompressionNone), WithMinMipSize(4), WithMipCap(edds.NoMipCap))
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var stored Result
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if stored.Options != res.Options {
+		t.Fatalf("stored options = %+v, want %+v", stored.Options, res.Options)
+	}
+
+	stored.Atlas = res.Atlas
+	var buf bytes.Buffer
+	if err := stored.WriteEDDS(&buf); err != nil {
+		t.Fatalf("WriteEDDS: %v", err)
+	}
+	img, err := edds.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != res.Width || b.Dy() != res.Height {
+		t.Fatalf("EDDS size = %v, want %dx%d", b, res.Width, res.Height)
+	}
+}
+
+func TestPackInvalid(t *testing.T) {
+	t.Parallel()
+
+	sprite := testSprite("a", "", 4, 4, color.NRGBA{A: 255})
+	tests := map[string]struct {
+		sprites []Sprite
+		opts    []Option
+	}{
+		"negative gap":  {sprites: []Sprite{sprite}, opts: []Option{WithGap(-1)}},
+		"unknown rule":  {sprites: []Sprite{sprite}, opts: []Option{WithRule("nope")}},
+		"bad workers":   {sprites: []Sprite{sprite}, opts: []Option{WithWorkers(-1)}},
+		"bad mip cap":   {sprites: []Sprite{sprite}, opts: []Option{WithMipCap(-2)}},
+		"compression":   {sprites: []Sprite{sprite}, opts: []Option{WithCompression("zip")}},
+		"no sprites":    {},
+		"duplicate":     {sprites: []Sprite{sprite, sprite}},
+		"missing image": {sprites: []Sprite{{Name: "a"}}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Pack(tt.sprites, tt.opts...); err == nil {
+				t.Fatal("Pack succeeded")
+			}
+		})
+	}
+
+	_, err := Pack([]Sprite{sprite}, WithGap(-1))
+	if !errors.Is(err, atlasforge.ErrInvalidOptions) {
+		t.Fatalf("Pack with negative gap = %v, want ErrInvalidOptions", err)
+	}
+}